@@ -23,6 +23,17 @@ else
 	vim.opt.number = true
 	vim.opt.relativenumber = true
 end
+if type(cfg.path_prepend) == "table" then
+	local sep = vim.fn.has("win32") == 1 and ";" or ":"
+	for i = #cfg.path_prepend, 1, -1 do
+		local dir = cfg.path_prepend[i]
+		local path = vim.env.PATH or ""
+		if type(dir) == "string" and dir ~= "" and not (sep .. path .. sep):find(sep .. dir .. sep, 1, true) then
+			vim.env.PATH = dir .. sep .. path
+		end
+	end
+end
+
 vim.opt.expandtab = true
 vim.opt.shiftwidth = 2
 vim.opt.tabstop = 2
@@ -2,13 +2,19 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/rivo/tview"
 
+	"nvimwiz/internal/cli"
 	"nvimwiz/internal/ui"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	app := tview.NewApplication()
 	w, err := ui.New(app)
 	if err != nil {
@@ -5,6 +5,8 @@ go 1.21
 require (
 	github.com/gdamore/tcell/v2 v2.8.1
 	github.com/rivo/tview v0.42.0
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/crypto v0.32.0
 )
 
 require (
@@ -114,6 +114,111 @@ How to verify
 			Default: true,
 			Modules: []string{"install/fd"},
 		},
+		{
+			ID:       "install.lazygit",
+			Category: "Install",
+			Title:    "lazygit",
+			Short:    "Terminal UI for git.",
+			Long: `What it does
+- Installs lazygit under ~/.local/bin.
+
+Why you want it
+- A fast keyboard-driven git UI for staging, commits, rebases and logs.
+- Plugins such as snacks.nvim and lazygit.nvim open it in a floating terminal.
+
+Repo
+- https://github.com/jesseduffield/lazygit
+
+How to verify
+- Run: lazygit --version`,
+			Default: false,
+			Modules: []string{"install/lazygit"},
+		},
+		{
+			ID:       "install.fzf",
+			Category: "Install",
+			Title:    "fzf",
+			Short:    "Fuzzy finder used by fzf-lua and shell pickers.",
+			Long: `What it does
+- Installs fzf under ~/.local/bin.
+
+Why you want it
+- fzf-lua and telescope-fzf extensions call the fzf binary.
+- Also handy on its own for shell history and file search.
+
+Repo
+- https://github.com/junegunn/fzf
+
+How to verify
+- Run: fzf --version`,
+			Default: false,
+			Modules: []string{"install/fzf"},
+		},
+		{
+			ID:       "install.treesitter",
+			Category: "Install",
+			Title:    "tree-sitter CLI",
+			Short:    "Builds parsers that nvim-treesitter cannot download.",
+			Long: `What it does
+- Installs the tree-sitter CLI under ~/.local/bin.
+
+Why you want it
+- nvim-treesitter uses it to generate parsers from grammar sources
+  (needed for some languages and on newer nvim-treesitter versions).
+- You still need a C compiler for parsers to build.
+
+Repo
+- https://github.com/tree-sitter/tree-sitter
+
+How to verify
+- Run: tree-sitter --version`,
+			Default: false,
+			Modules: []string{"install/treesitter"},
+		},
+		{
+			ID:       "install.jq",
+			Category: "Install",
+			Title:    "jq",
+			Short:    "JSON processor used by scripts and some plugins.",
+			Long: `What it does
+- Installs jq under ~/.local/bin.
+
+Why you want it
+- Formatting and querying JSON from Neovim (:%!jq .) and from the shell.
+- Some plugins and build scripts shell out to jq.
+
+Repo
+- https://github.com/jqlang/jq
+
+How to verify
+- Run: jq --version`,
+			Default: false,
+			Modules: []string{"install/jq"},
+		},
+		{
+			ID:       "install.node",
+			Category: "Install",
+			Title:    "Node.js",
+			Short:    "Node.js runtime for npm-based language servers.",
+			Long: `What it does
+- Downloads the official Node.js tarball (newest LTS unless pinned) into
+  ~/.local/node/<version> and links node, npm and npx into ~/.local/bin.
+- Verifies the tarball against the release's SHASUMS256.txt.
+
+Why you want it
+- The Python (pyright), TypeScript, Web and Bash language servers are
+  installed with npm and run on Node.js.
+- With "Install servers" enabled, Apply installs Node.js automatically when
+  no node 18 or newer with npm is found, even if this is off.
+
+Site
+- https://nodejs.org/dist
+
+How to verify
+- Run: node --version && npm --version`,
+			Default: false,
+			Modules: []string{"install/node"},
+		},
 		{
 			ID:       "config.write",
 			Category: "Core",
@@ -252,8 +357,8 @@ Why you want it
 - This is what makes Neovim behave like an IDE.
 
 Important note
-- This config sets up the client. You still need language servers installed
-  on your system for each language.
+- This config sets up the client. Enable "Install servers" to have nvimwiz
+  install the language servers, or install them yourself.
 
 Repo
 - https://github.com/neovim/nvim-lspconfig`,
@@ -261,6 +366,26 @@ Repo
 			Requires: []string{"core.completion"},
 			Modules:  []string{"lsp/core"},
 		},
+		{
+			ID:       "lsp.servers",
+			Category: "LSP",
+			Title:    "Install servers",
+			Short:    "Install language servers for the enabled languages.",
+			Long: `What it does
+- Installs the language server for each enabled LSP language into
+  ~/.local/share/nvimwiz/lsp (no sudo, no global npm packages):
+  - Go: gopls via go install
+  - Python, TypeScript, Web, Bash: npm packages under a private prefix
+  - Lua: lua-language-server release from GitHub
+- Adds ~/.local/share/nvimwiz/lsp/bin to PATH inside Neovim.
+
+Notes
+- Servers already on your PATH are left alone.
+- go install needs Go; npm-based servers need Node.js. Missing toolchains
+  are reported as warnings and the server is skipped.`,
+			Default:  true,
+			Requires: []string{"lsp.core"},
+		},
 		{
 			ID:       "lsp.go",
 			Category: "LSP",
@@ -269,8 +394,8 @@ Repo
 			Long: `What it does
 - Configures the Go language server (gopls) and common defaults.
 
-What you still need
-- Install gopls:
+Server
+- gopls, installed by "Install servers" (needs Go), or yourself:
   - go install golang.org/x/tools/gopls@latest
 
 Repo
@@ -287,8 +412,8 @@ Repo
 			Long: `What it does
 - Configures the Python language server (pyright).
 
-What you still need
-- Install pyright:
+Server
+- pyright, installed by "Install servers" (needs Node.js), or yourself:
   - npm i -g pyright
 
 Repo
@@ -305,11 +430,9 @@ Repo
 			Long: `What it does
 - Configures TypeScript/JavaScript LSP.
 
-What you still need
-- Requires Node.js and a language server installed via npm.
-
-Common servers
-- typescript-language-server
+Server
+- typescript-language-server, installed by "Install servers" (needs
+  Node.js), or yourself via npm.
 
 Repo
 - https://github.com/typescript-language-server/typescript-language-server`,
@@ -328,8 +451,9 @@ Repo
   - cssls
   - jsonls
 
-What you still need
-- Requires Node.js and the servers installed via npm.
+Servers
+- vscode-langservers-extracted, installed by "Install servers" (needs
+  Node.js), or yourself via npm.
 
 Tip
 - Enable this if you want a VS Code-like HTML/CSS editing experience.
@@ -349,8 +473,9 @@ Repos
 			Long: `What it does
 - Configures bash-language-server.
 
-What you still need
-- Install the server:
+Server
+- bash-language-server, installed by "Install servers" (needs Node.js),
+  or yourself:
   - npm i -g bash-language-server
 
 Repo
@@ -370,8 +495,9 @@ Repo
 Why you want it
 - If you customize your config (or write Lua), this makes it much nicer.
 
-What you still need
-- Install lua-language-server using your package manager.
+Server
+- lua-language-server, installed by "Install servers" from its GitHub
+  releases, or with your package manager.
 
 Repo
 - https://github.com/LuaLS/lua-language-server`,
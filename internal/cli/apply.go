@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/profile"
+	"nvimwiz/internal/tasks"
+)
+
+func runApply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	profileName := fs.String("profile", "", "profile to apply (default: the current profile)")
+	resumeFrom := fs.Int("resume-from", 1, "step number to start from, as reported by a failed run")
+	resume := fs.Bool("resume", false, "continue the last unfinished run if its profile has not changed since")
+	jobs := fs.Int("jobs", tasks.DefaultWorkers, "maximum number of independent tasks to run at once")
+	timeout := fs.String("timeout", "", "time limit for each task attempt, e.g. 10m (overrides the profile)")
+	attempts := fs.Int("attempts", 0, "tries per task before giving up (overrides the profile)")
+	backoff := fs.String("retry-backoff", "", "wait before the first retry, doubled after each attempt, e.g. 5s")
+	retryOn := fs.String("retry-on", "", "comma-separated errors worth a retry: timeout, network, 5xx, any or HTTP statuses such as 429 (overrides the profile)")
+	jsonOut := fs.Bool("json", false, "write events to stdout as JSON lines")
+	bundle := fs.String("bundle", "", "install from this bundle directory instead of the network")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	resumeFromSet := false
+	fs.Visit(func(f *flag.Flag) { resumeFromSet = resumeFromSet || f.Name == "resume-from" })
+	if *resume && resumeFromSet {
+		fmt.Fprintln(os.Stderr, "--resume and --resume-from cannot be used together: --resume continues after the last completed step")
+		return 2
+	}
+
+	cat := catalog.Get()
+
+	var last *tasks.Journal
+	if *resume {
+		j, ok, err := tasks.LoadJournal()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+			return 1
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "No previous run to resume")
+			return 1
+		}
+		if strings.TrimSpace(*profileName) == "" {
+			*profileName = j.Profile
+		}
+		last = j
+	}
+
+	p, err := loadProfile(*profileName, cat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+
+	if *bundle != "" {
+		p.Bundle = *bundle
+	}
+
+	pol := profile.TaskPolicy{Timeout: *timeout, Attempts: *attempts, Backoff: *backoff}
+	for _, c := range strings.Split(*retryOn, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			pol.RetryOn = append(pol.RetryOn, c)
+		}
+	}
+	if _, err := tasks.RetryOn(pol.RetryOn); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	for _, v := range []string{pol.Timeout, pol.Backoff} {
+		if v == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			fmt.Fprintln(os.Stderr, "invalid duration: "+v)
+			return 2
+		}
+	}
+
+	for _, w := range tasks.PolicyWarnings(p) {
+		fmt.Fprintln(os.Stderr, "Warning: "+w)
+	}
+	plan := tasks.Plan(p, cat)
+	tasks.ApplyPolicy(plan, pol)
+	total := len(plan)
+	if *resumeFrom < 1 || (total > 0 && *resumeFrom > total) {
+		fmt.Fprintf(os.Stderr, "--resume-from must be between 1 and %d\n", total)
+		return 2
+	}
+	start := *resumeFrom - 1
+
+	journal := tasks.NewJournal(p, plan)
+	var st *tasks.State
+	if last != nil {
+		idx, ok := last.ResumeIndex(p, plan)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "The last run cannot be resumed: it finished, or profile "+p.Name+" changed since. Run without --resume.")
+			return 1
+		}
+		journal = last
+		st = last.ResumeState()
+		start = idx
+	}
+
+	// Human-readable status goes to stderr when stdout carries JSON events.
+	info := os.Stdout
+	emit := events.TextLines(os.Stdout)
+	if *jsonOut {
+		info = os.Stderr
+		emit = events.JSONLines(os.Stdout)
+	}
+
+	if changed, lb, err := env.EnsureLocalBinInPath(); err == nil && changed {
+		fmt.Fprintln(info, "Added "+lb+" to PATH for this run")
+	}
+	if start > 0 && start < total {
+		fmt.Fprintf(info, "Resuming at %d/%d: %s\n", start+1, total, plan[start].Name)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	began := time.Now()
+	if st == nil {
+		st = &tasks.State{}
+	}
+	st, failedAt, err := tasks.RunFrom(ctx, plan, st, start, *jobs, journal.Track(st, emit))
+	if jerr := journal.Finish(st, failedAt, err); jerr != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not save run journal: "+jerr.Error())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "")
+		if errors.Is(err, context.Canceled) && failedAt >= 0 && failedAt < total {
+			fmt.Fprintf(os.Stderr, "Interrupted at %d/%d (%s)\n", failedAt+1, total, plan[failedAt].Name)
+			fmt.Fprintln(os.Stderr, "Resume with: nvimwiz apply --resume")
+		} else if failedAt >= 0 && failedAt < total {
+			fmt.Fprintf(os.Stderr, "Failed at %d/%d (%s): %s\n", failedAt+1, total, plan[failedAt].Name, err.Error())
+			var xe *install.ExtractError
+			if errors.As(err, &xe) {
+				fmt.Fprintln(os.Stderr, xe.Explain())
+			}
+			fmt.Fprintf(os.Stderr, "Resume with: nvimwiz apply --profile %s --resume-from %d\n", p.Name, failedAt+1)
+		} else {
+			fmt.Fprintln(os.Stderr, "Failed: "+err.Error())
+		}
+		return 1
+	}
+
+	fmt.Fprintln(info, "")
+	fmt.Fprintln(info, "Done in "+time.Since(began).Round(time.Second).String())
+	if p.ConfigMode == "integrate" {
+		fmt.Fprintln(info, "Integrate mode: add require(\"nvimwiz.loader\") to your init.lua")
+	}
+	return 0
+}
+
+func loadProfile(name string, cat catalog.Catalog) (profile.Profile, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		_, p, _, err := profile.LoadCurrent(cat)
+		return p, err
+	}
+	ok, err := profile.Exists(name)
+	if err != nil {
+		return profile.Profile{}, err
+	}
+	if !ok && name != "default" {
+		return profile.Profile{}, fmt.Errorf("profile %q not found", name)
+	}
+	p, _, err := profile.LoadByName(name, cat)
+	return p, err
+}
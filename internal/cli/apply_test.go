@@ -0,0 +1,10 @@
+package cli
+
+import "testing"
+
+func TestApplyRejectsResumeWithResumeFrom(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if code := runApply([]string{"--resume", "--resume-from", "2"}); code != 2 {
+		t.Fatalf("exit code %d, want 2", code)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/tasks"
+)
+
+func runBundle(args []string) int {
+	if len(args) == 0 {
+		bundleUsage(os.Stderr)
+		return 2
+	}
+	sub, rest := strings.TrimSpace(args[0]), args[1:]
+	switch sub {
+	case "create":
+		return runBundleCreate(rest)
+	case "help", "-h", "-help", "--help":
+		bundleUsage(os.Stdout)
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "unknown bundle command: "+sub)
+	bundleUsage(os.Stderr)
+	return 2
+}
+
+func bundleUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: nvimwiz bundle create [--profile NAME] <dir>")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Downloads every release the profile installs into <dir>, for this machine's")
+	fmt.Fprintln(out, "OS and architecture. Copy <dir> to an offline machine and run:")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "  nvimwiz apply --bundle <dir>")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Language servers installed with go or npm cannot be bundled.")
+}
+
+func runBundleCreate(args []string) int {
+	fs := flag.NewFlagSet("bundle create", flag.ContinueOnError)
+	profileName := fs.String("profile", "", "profile whose tools to bundle (default: the current profile)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		bundleUsage(os.Stderr)
+		return 2
+	}
+
+	cat := catalog.Get()
+	p, err := loadProfile(*profileName, cat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	p.Bundle = ""
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	b := &install.Bundler{Dir: fs.Arg(0), Emit: events.TextLines(os.Stdout)}
+	failed := false
+	check := func(what string, err error) {
+		switch {
+		case err == nil:
+		case errors.Is(err, install.ErrOffline):
+			b.Emit.Warn(err.Error() + "; it will be skipped offline")
+		default:
+			fmt.Fprintln(os.Stderr, "Error: "+what+": "+err.Error())
+			failed = true
+		}
+	}
+
+	for _, t := range install.Tools() {
+		if p.Features[t.FeatureID] {
+			check(t.Title, b.AddTool(ctx, t, tasks.InstallOptions(p, t.Name)))
+		}
+	}
+	if bound := p.BoundNvimVersion(); bound != "" {
+		t, _ := install.LookupTool("neovim")
+		opts := tasks.InstallOptions(p, "neovim")
+		opts.Version = bound
+		check("Neovim "+bound, b.AddTool(ctx, t, opts))
+	}
+	// The offline machine may lack Node.js even when this one has it.
+	if p.Features[install.NodeFeatureID] || install.NeedsNode(p.Features) {
+		check("Node.js", b.AddNode(ctx, tasks.InstallOptions(p, "node")))
+	}
+	if p.Features["lsp.servers"] {
+		for _, s := range install.Servers() {
+			if p.Features[s.FeatureID] {
+				check(s.Name, b.AddServer(ctx, s, tasks.InstallOptions(p, s.Name)))
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		return 1
+	}
+	if err := b.Finish(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	fmt.Printf("Bundle for %s/%s written to %s\n", runtime.GOOS, runtime.GOARCH, b.Dir)
+	return 0
+}
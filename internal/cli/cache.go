@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/install"
+)
+
+func runCache(args []string) int {
+	if len(args) == 0 {
+		cacheUsage(os.Stderr)
+		return 2
+	}
+	sub, rest := strings.TrimSpace(args[0]), args[1:]
+	if len(rest) > 0 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments: "+strings.Join(rest, " "))
+		return 2
+	}
+	switch sub {
+	case "info":
+		return runCacheInfo()
+	case "clean":
+		return runCacheClean()
+	case "help", "-h", "-help", "--help":
+		cacheUsage(os.Stdout)
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "unknown cache command: "+sub)
+	cacheUsage(os.Stderr)
+	return 2
+}
+
+func cacheUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: nvimwiz cache <info|clean>")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "  info    Show where downloads are cached and how much space they use")
+	fmt.Fprintln(out, "  clean   Delete every cached download and release lookup")
+	fmt.Fprintln(out, "")
+	fmt.Fprintf(out, "The cache is capped at %d MiB; set NVIMWIZ_CACHE_MAX_MB to change it (0 disables caching).\n", install.DefaultCacheMaxBytes>>20)
+}
+
+func runCacheInfo() int {
+	dir, err := install.CacheDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	files, size, err := install.CacheSize()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	fmt.Println("Cache: " + dir)
+	fmt.Printf("%d files, %s\n", files, events.FormatBytes(size))
+	return 0
+}
+
+func runCacheClean() int {
+	freed, err := install.CleanCache()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	fmt.Println("Freed " + events.FormatBytes(freed))
+	return 0
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type command struct {
+	Name  string
+	Short string
+	Run   func(args []string) int
+}
+
+func commands() []command {
+	return []command{
+		{Name: "plan", Short: "Show what apply would do without changing anything", Run: runPlan},
+		{Name: "apply", Short: "Run a profile's task plan without the TUI", Run: runApply},
+		{Name: "nvim", Short: "List, switch, remove or prune installed Neovim versions", Run: runNvim},
+		{Name: "cache", Short: "Show or clean the download cache", Run: runCache},
+		{Name: "bundle", Short: "Download a profile's releases for offline installs", Run: runBundle},
+	}
+}
+
+// Run dispatches a subcommand and returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return 2
+	}
+	name := strings.TrimSpace(args[0])
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return 0
+	}
+	for _, c := range commands() {
+		if c.Name == name {
+			return c.Run(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "unknown command: "+name)
+	usage(os.Stderr)
+	return 2
+}
+
+func usage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: nvimwiz [command] [flags]")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Without a command nvimwiz starts the interactive wizard.")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(out, "  %-10s %s\n", c.Name, c.Short)
+	}
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Run 'nvimwiz <command> -h' for command flags.")
+}
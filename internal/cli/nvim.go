@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/tasks"
+)
+
+func runNvim(args []string) int {
+	if len(args) == 0 {
+		nvimUsage(os.Stderr)
+		return 2
+	}
+	sub, rest := strings.TrimSpace(args[0]), args[1:]
+	switch sub {
+	case "list", "ls":
+		return runNvimList(rest)
+	case "use":
+		return runNvimUse(rest)
+	case "remove", "rm":
+		return runNvimRemove(rest)
+	case "prune":
+		return runNvimPrune(rest)
+	case "help", "-h", "-help", "--help":
+		nvimUsage(os.Stdout)
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "unknown nvim command: "+sub)
+	nvimUsage(os.Stderr)
+	return 2
+}
+
+func nvimUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: nvimwiz nvim <list|use|remove|prune> [args]")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "  list            Show Neovim versions installed under ~/.local/nvim")
+	fmt.Fprintln(out, "  use <tag>       Point ~/.local/bin/nvim at an installed version")
+	fmt.Fprintln(out, "  remove <tag>    Delete an installed version (not the active one or one a launcher uses)")
+	fmt.Fprintf(out, "  prune [--keep N] Keep the active and N newest other versions (default %d)\n", install.DefaultNvimKeep)
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Versions a profile's launcher is bound to (nvimVersion), or that a launcher runs,")
+	fmt.Fprintln(out, "are never removed or pruned.")
+}
+
+func nvimTagArg(name string, args []string) (string, bool) {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		fmt.Fprintln(os.Stderr, "Usage: nvimwiz nvim "+name+" <tag>")
+		return "", false
+	}
+	return strings.TrimSpace(args[0]), true
+}
+
+func runNvimList(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments: "+strings.Join(args, " "))
+		return 2
+	}
+	items, err := install.ListNvimVersions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	if len(items) == 0 {
+		fmt.Println("No Neovim versions installed by nvimwiz")
+		return 0
+	}
+	for _, v := range items {
+		mark := " "
+		if v.Active {
+			mark = "*"
+		}
+		fmt.Printf("%s %-24s %s\n", mark, v.Tag, v.Dir)
+	}
+	return 0
+}
+
+func runNvimUse(args []string) int {
+	tag, ok := nvimTagArg("use", args)
+	if !ok {
+		return 2
+	}
+	v, err := install.UseNvimVersion(tag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	fmt.Println("nvim now runs " + v.Tag + " (" + v.Bin + ")")
+	return 0
+}
+
+func runNvimRemove(args []string) int {
+	tag, ok := nvimTagArg("remove", args)
+	if !ok {
+		return 2
+	}
+	pins, err := tasks.NvimPins(catalog.Get())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	if err := install.RemoveNvimVersion(tag, pins...); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	fmt.Println("Removed Neovim " + tag)
+	return 0
+}
+
+func runNvimPrune(args []string) int {
+	fs := flag.NewFlagSet("nvim prune", flag.ContinueOnError)
+	keep := fs.Int("keep", install.DefaultNvimKeep, "number of versions to keep besides the active one")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+		return 2
+	}
+	pins, err := tasks.NvimPins(catalog.Get())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	removed, err := install.PruneNvimVersions(*keep, pins...)
+	for _, tag := range removed {
+		fmt.Println("Removed Neovim " + tag)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	if len(removed) == 0 {
+		fmt.Println("Nothing to prune")
+	}
+	return 0
+}
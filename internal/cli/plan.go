@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/tasks"
+)
+
+func runPlan(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	profileName := fs.String("profile", "", "profile to preview (default: the current profile)")
+	bundle := fs.String("bundle", "", "preview an offline install from this bundle directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	cat := catalog.Get()
+	p, err := loadProfile(*profileName, cat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: "+err.Error())
+		return 1
+	}
+	if *bundle != "" {
+		p.Bundle = *bundle
+	}
+	_, _, _ = env.EnsureLocalBinInPath()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, w := range tasks.PolicyWarnings(p) {
+		fmt.Fprintln(os.Stderr, "Warning: "+w)
+	}
+	plan := tasks.Plan(p, cat)
+	fmt.Println("Plan for profile " + p.Name + " (dry run, nothing is changed)")
+	fmt.Println("")
+	for _, line := range tasks.FormatPreview(tasks.Preview(ctx, plan)) {
+		fmt.Println(line)
+	}
+	return 0
+}
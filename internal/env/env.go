@@ -29,3 +29,42 @@ func EnsureLocalBinInPath() (bool, string, error) {
 	os.Setenv("PATH", lb+sep+os.Getenv("PATH"))
 	return true, lb, nil
 }
+
+// DataDir is where nvimwiz keeps installed data such as language servers:
+// $XDG_DATA_HOME/nvimwiz, or ~/.local/share/nvimwiz.
+func DataDir() (string, error) {
+	if v := strings.TrimSpace(os.Getenv("XDG_DATA_HOME")); v != "" {
+		return filepath.Join(v, "nvimwiz"), nil
+	}
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, ".local", "share", "nvimwiz"), nil
+}
+
+// CacheDir is where nvimwiz keeps data it can refetch, such as downloaded
+// archives: $XDG_CACHE_HOME/nvimwiz, or ~/.cache/nvimwiz.
+func CacheDir() (string, error) {
+	if v := strings.TrimSpace(os.Getenv("XDG_CACHE_HOME")); v != "" {
+		return filepath.Join(v, "nvimwiz"), nil
+	}
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, ".cache", "nvimwiz"), nil
+}
+
+// StateDir is where nvimwiz records what it did, such as install receipts:
+// $XDG_STATE_HOME/nvimwiz, or ~/.local/state/nvimwiz.
+func StateDir() (string, error) {
+	if v := strings.TrimSpace(os.Getenv("XDG_STATE_HOME")); v != "" {
+		return filepath.Join(v, "nvimwiz"), nil
+	}
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, ".local", "state", "nvimwiz"), nil
+}
@@ -8,7 +8,10 @@ import (
 	"strings"
 )
 
-func CreateNvimAppLauncher(appName string) (string, error) {
+// CreateNvimAppLauncher writes ~/.local/bin/<appName>, a script that runs
+// Neovim with NVIM_APPNAME set. nvimBin pins the binary it execs; when empty
+// the script runs whatever nvim is first on PATH.
+func CreateNvimAppLauncher(appName, nvimBin string) (string, error) {
 	appName = strings.TrimSpace(appName)
 	if appName == "" {
 		return "", fmt.Errorf("app name is empty")
@@ -30,10 +33,47 @@ func CreateNvimAppLauncher(appName string) (string, error) {
 		return "", fmt.Errorf("launcher path is a directory")
 	}
 
-	script := "#!/usr/bin/env sh\nexport NVIM_APPNAME=\"" + appName + "\"\nexec nvim \"$@\"\n"
+	exe := "nvim"
+	if strings.TrimSpace(nvimBin) != "" {
+		exe = "\"" + nvimBin + "\""
+	}
+	script := "#!/usr/bin/env sh\nexport NVIM_APPNAME=\"" + appName + "\"\nexec " + exe + " \"$@\"\n"
 	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
 		return "", err
 	}
 	_ = os.Chmod(path, 0o755)
 	return path, nil
 }
+
+// NvimAppLauncherPath returns where CreateNvimAppLauncher writes the
+// launcher for appName.
+func NvimAppLauncherPath(appName string) (string, error) {
+	lb, err := LocalBin()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(lb, strings.TrimSpace(appName)), nil
+}
+
+// NvimAppLauncherTarget returns the Neovim binary the launcher for appName
+// execs, or "" when there is no launcher or it runs nvim from PATH.
+func NvimAppLauncherTarget(appName string) string {
+	path, err := NvimAppLauncherPath(appName)
+	if err != nil {
+		return ""
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "exec \"")
+		if !ok {
+			continue
+		}
+		if bin, _, ok := strings.Cut(rest, "\""); ok {
+			return bin
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	TaskStarted       Kind = "task_started"
+	TaskFinished      Kind = "task_finished"
+	TaskFailed        Kind = "task_failed"
+	TaskCancelled     Kind = "task_cancelled"
+	Log               Kind = "log"
+	Warning           Kind = "warning"
+	DownloadProgress  Kind = "download_progress"
+	ChecksumVerified  Kind = "checksum_verified"
+	ChecksumSkipped   Kind = "checksum_skipped"
+	SignatureVerified Kind = "signature_verified"
+)
+
+type Event struct {
+	Kind       Kind      `json:"kind"`
+	Time       time.Time `json:"time"`
+	Task       string    `json:"task,omitempty"`
+	Step       int       `json:"step,omitempty"`
+	Total      int       `json:"total,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	File       string    `json:"file,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	Bytes      int64     `json:"bytes,omitempty"`
+	TotalBytes int64     `json:"totalBytes,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Sink receives events. A nil Sink discards everything, so producers can
+// call its methods without checking.
+type Sink func(Event)
+
+func (s Sink) Emit(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s(e)
+}
+
+func (s Sink) Log(msg string) {
+	s.Emit(Event{Kind: Log, Message: msg})
+}
+
+func (s Sink) Warn(msg string) {
+	s.Emit(Event{Kind: Warning, Message: msg})
+}
+
+// WithTask stamps every event passing through with the task it belongs to.
+func (s Sink) WithTask(name string, step, total int) Sink {
+	if s == nil {
+		return nil
+	}
+	return func(e Event) {
+		if e.Task == "" {
+			e.Task = name
+			e.Step = step
+			e.Total = total
+		}
+		s(e)
+	}
+}
+
+// Text renders an event as a human-readable log line. It returns false for
+// events that have no log representation, such as download progress.
+func Text(e Event) (string, bool) {
+	switch e.Kind {
+	case TaskStarted:
+		return "== " + e.Task + " ==", true
+	case TaskFailed:
+		return "Error: " + e.Error, true
+	case TaskCancelled:
+		return "Cancelled: " + e.Task, true
+	case Warning:
+		return "Warning: " + e.Message, true
+	case Log, ChecksumVerified, ChecksumSkipped, SignatureVerified:
+		return e.Message, e.Message != ""
+	default:
+		return "", false
+	}
+}
+
+// TextLines writes the Text form of each event to w, one per line.
+func TextLines(w io.Writer) Sink {
+	var mu sync.Mutex
+	return func(e Event) {
+		line, ok := Text(e)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, strings.TrimRight(line, "\n"))
+	}
+}
+
+// JSONLines writes each event to w as a single JSON object per line.
+func JSONLines(w io.Writer) Sink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(e)
+	}
+}
+
+// FormatBytes renders n using binary units, e.g. "3.2 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,305 @@
+package install
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// ErrOffline is returned for installs that need the network while a
+// bundle is in use, such as go install and npm.
+var ErrOffline = errors.New("not available offline")
+
+// A bundle is a directory that mirrors the URLs an install requests:
+// https://api.github.com/repos/neovim/neovim/releases/latest is stored at
+// <bundle>/api.github.com/repos/neovim/neovim/releases/latest and a release
+// asset at <bundle>/github.com/neovim/neovim/releases/download/<tag>/<name>.
+// SHA256SUMS at the top lists every file so a copied bundle can be checked.
+const bundleSums = "SHA256SUMS"
+
+// bundlePath maps a URL to its file in the bundle.
+func bundlePath(dir, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(pathClean(u.Path), "/"))
+	if u.Host == "" || rel == "" || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("cannot map %s into a bundle", rawURL)
+	}
+	return filepath.Join(dir, u.Host, rel), nil
+}
+
+func pathClean(p string) string {
+	return filepath.ToSlash(filepath.Clean("/" + p))
+}
+
+// bundleTransport answers requests from a bundle directory. Files are
+// checked against the bundle's SHA256SUMS before they are served.
+type bundleTransport struct {
+	dir string
+
+	once sync.Once
+	sums map[string]string
+	err  error
+}
+
+var bundleTransports = struct {
+	sync.Mutex
+	m map[string]*bundleTransport
+}{m: map[string]*bundleTransport{}}
+
+func bundleClient(dir string) *http.Client {
+	bundleTransports.Lock()
+	defer bundleTransports.Unlock()
+	t := bundleTransports.m[dir]
+	if t == nil {
+		t = &bundleTransport{dir: dir}
+		bundleTransports.m[dir] = t
+	}
+	return &http.Client{Transport: t}
+}
+
+func (t *bundleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { t.sums, t.err = readBundleSums(t.dir) })
+	if t.err != nil {
+		return nil, t.err
+	}
+	p, err := bundlePath(t.dir, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	got, err := receipt.FileSHA256(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			msg := "not in bundle: " + req.URL.String()
+			return bundleResponse(req, http.StatusNotFound, io.NopCloser(strings.NewReader(msg)), int64(len(msg))), nil
+		}
+		return nil, err
+	}
+	rel, _ := filepath.Rel(t.dir, p)
+	want, ok := t.sums[filepath.ToSlash(rel)]
+	if !ok {
+		return nil, fmt.Errorf("bundle file %s is not listed in %s", rel, bundleSums)
+	}
+	if got != want {
+		return nil, fmt.Errorf("bundle file %s is corrupt (sha256 %s, expected %s)", rel, got, want)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return bundleResponse(req, http.StatusOK, f, fi.Size()), nil
+}
+
+func bundleResponse(req *http.Request, status int, body io.ReadCloser, size int64) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          body,
+		ContentLength: size,
+		Request:       req,
+	}
+}
+
+func readBundleSums(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, bundleSums))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s is not an nvimwiz bundle (no %s)", dir, bundleSums)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	m := map[string]string{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		sum, name, ok := strings.Cut(strings.TrimSpace(s.Text()), "  ")
+		if ok {
+			m[strings.TrimSpace(name)] = strings.ToLower(sum)
+		}
+	}
+	return m, s.Err()
+}
+
+// Bundler collects releases into a bundle directory for offline installs.
+// Each release is fetched online through the source in the given Options.
+type Bundler struct {
+	Dir  string
+	Emit events.Sink
+}
+
+// AddTool stores the release of t matching opts.Version with its asset
+// and checksum files.
+func (b *Bundler) AddTool(ctx context.Context, t ToolSpec, opts Options) error {
+	opts.Bundle = ""
+	r := opts.remote()
+	spec := t.releaseSpec(opts.Version)
+	rel, err := fetchRelease(ctx, r, t.Owner, t.Repo, spec)
+	if err != nil {
+		return err
+	}
+	asset, _, err := t.pickAsset(rel)
+	if err != nil {
+		return err
+	}
+
+	// Store the metadata where an offline lookup of spec asks for it, and
+	// under the concrete tag for pinned lookups.
+	endpoints := []string{DefaultGitHubAPI + "/repos/" + t.Owner + "/" + t.Repo + "/releases/tags/" + url.PathEscape(rel.TagName)}
+	if spec == "" || strings.EqualFold(spec, "latest") {
+		endpoints = append(endpoints, DefaultGitHubAPI+"/repos/"+t.Owner+"/"+t.Repo+"/releases/latest")
+	} else if spec != rel.TagName {
+		endpoints = append(endpoints, DefaultGitHubAPI+"/repos/"+t.Owner+"/"+t.Repo+"/releases/tags/"+url.PathEscape(spec))
+	}
+	meta, err := json.Marshal(rel)
+	if err != nil {
+		return err
+	}
+	for _, ep := range endpoints {
+		if err := b.writeURL(ep, meta); err != nil {
+			return err
+		}
+	}
+
+	files := []ghAsset{asset}
+	for _, a := range rel.Assets {
+		if a.Name != asset.Name && verificationFile(asset.Name, a.Name) {
+			files = append(files, a)
+		}
+	}
+	for _, a := range files {
+		if err := b.fetchURL(ctx, r, a.BrowserDownloadURL); err != nil {
+			return err
+		}
+	}
+	b.Emit.Log("Bundled " + t.Owner + "/" + t.Repo + " " + rel.TagName)
+	return nil
+}
+
+// AddNode stores the Node.js index, the tarball for this platform and its
+// SHASUMS256.txt.
+func (b *Bundler) AddNode(ctx context.Context, opts Options) error {
+	opts.Bundle = ""
+	r := opts.remote()
+	rels, err := fetchNodeIndex(ctx, r)
+	if err != nil {
+		return err
+	}
+	rel, err := pickNodeRelease(rels, opts.Version)
+	if err != nil {
+		return err
+	}
+	name, err := nodeAssetName(rel)
+	if err != nil {
+		return err
+	}
+	// Only the chosen release goes into the bundle's index, so "lts" and
+	// "latest" resolve to it offline.
+	index, err := json.Marshal([]nodeRelease{rel})
+	if err != nil {
+		return err
+	}
+	if err := b.writeURL(nodeDistURL+"/index.json", index); err != nil {
+		return err
+	}
+	for _, u := range []string{nodeDistURL + "/" + rel.Version + "/" + name, nodeDistURL + "/" + rel.Version + "/SHASUMS256.txt"} {
+		if err := b.fetchURL(ctx, r, u); err != nil {
+			return err
+		}
+	}
+	b.Emit.Log("Bundled Node.js " + rel.Version)
+	return nil
+}
+
+// AddServer stores a language server released on GitHub. Servers installed
+// with go or npm cannot be bundled.
+func (b *Bundler) AddServer(ctx context.Context, s ServerSpec, opts Options) error {
+	if s.Method != GitHubRelease {
+		return fmt.Errorf("%w: %s is installed with %s", ErrOffline, s.Name, s.Method)
+	}
+	opts.Version = ""
+	return b.AddTool(ctx, *s.Release, opts)
+}
+
+// Finish writes the bundle's SHA256SUMS.
+func (b *Bundler) Finish() error {
+	lines := []string{}
+	err := filepath.WalkDir(b.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || p == filepath.Join(b.Dir, bundleSums) {
+			return nil
+		}
+		sum, err := receipt.FileSHA256(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(b.Dir, p)
+		lines = append(lines, sum+"  "+filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+	return writeFileAtomic(filepath.Join(b.Dir, bundleSums), []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// verificationFile reports whether the release file name is a checksum or
+// signature file that verifying asset may need.
+func verificationFile(asset, name string) bool {
+	for _, ext := range signatureExts {
+		if base := strings.TrimSuffix(name, ext); base != name {
+			if base == asset {
+				return true
+			}
+			name = base
+			break
+		}
+	}
+	return strings.HasPrefix(name, asset+".sha256") || isChecksumList(name)
+}
+
+func (b *Bundler) writeURL(rawURL string, data []byte) error {
+	p, err := bundlePath(b.Dir, rawURL)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p, data)
+}
+
+func (b *Bundler) fetchURL(ctx context.Context, r remote, rawURL string) error {
+	p, err := bundlePath(b.Dir, rawURL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return downloadFile(ctx, r, rawURL, p, b.Emit)
+}
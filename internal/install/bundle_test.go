@@ -0,0 +1,161 @@
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBundle writes a bundle holding release v1.0.0 of o/r with one asset
+// and its SHA256SUMS, and returns the bundle dir and the asset URL.
+func testBundle(t *testing.T) (dir, assetURL string) {
+	t.Helper()
+	dir = t.TempDir()
+	b := &Bundler{Dir: dir}
+	assetURL = "https://github.com/o/r/releases/download/v1.0.0/r-linux.tar.gz"
+	rel := ghRelease{TagName: "v1.0.0", Assets: []ghAsset{{Name: "r-linux.tar.gz", BrowserDownloadURL: assetURL}}}
+	meta, err := json.Marshal(rel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []struct{ url, body string }{
+		{DefaultGitHubAPI + "/repos/o/r/releases/latest", string(meta)},
+		{DefaultGitHubAPI + "/repos/o/r/releases/tags/v1.0.0", string(meta)},
+		{assetURL, "archive bytes"},
+	} {
+		if err := b.writeURL(f.url, []byte(f.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return dir, assetURL
+}
+
+func TestBundleServesReleases(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir, assetURL := testBundle(t)
+	r := Options{Bundle: dir}.remote()
+	if !r.offline {
+		t.Fatal("bundle remote is not offline")
+	}
+
+	for _, spec := range []string{"", "v1.0.0", "1.0.0"} {
+		rel, err := fetchRelease(context.Background(), r, "o", "r", spec)
+		if err != nil || rel.TagName != "v1.0.0" {
+			t.Fatalf("fetchRelease(%q) = %q, %v", spec, rel.TagName, err)
+		}
+	}
+	if _, err := fetchRelease(context.Background(), r, "o", "r", "v2.0.0"); err == nil {
+		t.Fatal("found a release that is not in the bundle")
+	}
+
+	dst := filepath.Join(t.TempDir(), "r-linux.tar.gz")
+	if err := downloadFile(context.Background(), r, assetURL, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(dst); string(b) != "archive bytes" {
+		t.Fatalf("downloaded %q", b)
+	}
+	if _, ok := cachedDownload(assetURL); ok {
+		t.Fatal("bundle file copied into the download cache")
+	}
+}
+
+func TestBundleRefusesTamperedFiles(t *testing.T) {
+	dir, assetURL := testBundle(t)
+	asset, err := bundlePath(dir, assetURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(url string) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		return bundleClient(dir).Transport.RoundTrip(req)
+	}
+
+	resp, err := get(assetURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "archive bytes" {
+		t.Fatalf("served %q", b)
+	}
+
+	if err := os.WriteFile(asset, []byte("swapped bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(assetURL); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("tampered file: %v", err)
+	}
+
+	extra := "https://github.com/o/r/releases/download/v1.0.0/extra"
+	p, _ := bundlePath(dir, extra)
+	if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(extra); err == nil || !strings.Contains(err.Error(), "not listed") {
+		t.Fatalf("unlisted file: %v", err)
+	}
+
+	resp, err = get("https://github.com/o/r/releases/download/v1.0.0/missing")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing file: %v, %v", resp, err)
+	}
+	resp.Body.Close()
+}
+
+func TestBundleNeedsSums(t *testing.T) {
+	dir := t.TempDir()
+	req, _ := http.NewRequest(http.MethodGet, "https://github.com/o/r/x", nil)
+	if _, err := bundleClient(dir).Transport.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "not an nvimwiz bundle") {
+		t.Fatalf("bundle without %s: %v", bundleSums, err)
+	}
+}
+
+func TestBundlePathStaysInside(t *testing.T) {
+	dir := t.TempDir()
+	for _, u := range []string{
+		"https://github.com/../../etc/passwd",
+		"https://github.com/a/%2e%2e/%2e%2e/%2e%2e/etc",
+		"https://api.github.com/repos/o/r/releases/latest",
+	} {
+		p, err := bundlePath(dir, u)
+		if err != nil {
+			continue
+		}
+		if rel, _ := filepath.Rel(dir, p); strings.HasPrefix(rel, "..") {
+			t.Errorf("bundlePath(%s) = %s, outside the bundle", u, p)
+		}
+	}
+	if _, err := bundlePath(dir, "/no/host"); err == nil {
+		t.Error("bundlePath accepted a URL without a host")
+	}
+}
+
+func TestVerificationFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"r-linux.tar.gz.sha256":       true,
+		"r-linux.tar.gz.sha256sum":    true,
+		"r-linux.tar.gz.minisig":      true,
+		"r-linux.tar.gz.sig":          true,
+		"SHA256SUMS":                  true,
+		"SHA256SUMS.minisig":          true,
+		"r_1.0.0_checksums.txt":       true,
+		"r-macos.tar.gz":              false,
+		"r-macos.tar.gz.sha256":       false,
+		"r-macos.tar.gz.sig":          false,
+		"r-linux.tar.gz.intoto.jsonl": false,
+	} {
+		if got := verificationFile("r-linux.tar.gz", name); got != want {
+			t.Errorf("verificationFile(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
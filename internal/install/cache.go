@@ -0,0 +1,319 @@
+package install
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// DefaultCacheMaxBytes bounds the download cache unless NVIMWIZ_CACHE_MAX_MB
+// says otherwise.
+const DefaultCacheMaxBytes = 1 << 30
+
+// The download cache stores each file once under blobs/<sha256>, and
+// urls/<sha256 of the URL> names the blob a URL downloaded to. Blobs are
+// re-hashed before use, so a damaged entry is refetched rather than
+// installed.
+
+// CacheDir is the download cache shared by every profile and run.
+func CacheDir() (string, error) {
+	d, err := env.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "downloads"), nil
+}
+
+func cacheMaxBytes() int64 {
+	if v := strings.TrimSpace(os.Getenv("NVIMWIZ_CACHE_MAX_MB")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			return n << 20
+		}
+	}
+	return DefaultCacheMaxBytes
+}
+
+// cacheable reports whether url names content that never changes. Assets
+// of moving tags ("nightly", "stable", "latest/download") are always
+// fetched.
+func cacheable(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
+	for _, seg := range strings.Split(u.Path, "/") {
+		if isReleaseChannel(seg) || strings.EqualFold(seg, "latest") {
+			return false
+		}
+	}
+	return true
+}
+
+func urlKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedDownload returns the blob url was last downloaded to, if it is
+// still present and intact.
+func cachedDownload(rawURL string) (string, bool) {
+	if !cacheable(rawURL) {
+		return "", false
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return "", false
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "urls", urlKey(rawURL)))
+	if err != nil {
+		return "", false
+	}
+	sum, _, _ := strings.Cut(string(b), "\n")
+	sum = strings.TrimSpace(sum)
+	if len(sum) != sha256.Size*2 {
+		return "", false
+	}
+	blob := filepath.Join(dir, "blobs", sum)
+	got, err := receipt.FileSHA256(blob)
+	if err != nil || got != sum {
+		_ = os.Remove(blob)
+		forgetDownload(rawURL)
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(blob, now, now)
+	return blob, true
+}
+
+// commitDownload adds path, fetched from url by downloadFile and since
+// verified, to the download cache. Files served from a bundle are not
+// cached.
+func commitDownload(r remote, rawURL, path string, emit events.Sink) {
+	if r.offline {
+		return
+	}
+	if err := storeDownload(rawURL, path); err != nil {
+		emit.Warn("Could not cache " + filepath.Base(path) + ": " + err.Error())
+	}
+}
+
+// storeDownload adds the file at path to the cache as the content of url,
+// then evicts the least recently used blobs beyond the size limit.
+func storeDownload(rawURL, path string) error {
+	if !cacheable(rawURL) {
+		return nil
+	}
+	max := cacheMaxBytes()
+	if max == 0 {
+		return nil
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return err
+	}
+	sum, err := receipt.FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	blob := filepath.Join(dir, "blobs", sum)
+	if _, err := os.Stat(blob); err != nil {
+		if err := copyFileAtomic(path, blob); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "urls", urlKey(rawURL)), []byte(sum+"\n"+rawURL+"\n")); err != nil {
+		return err
+	}
+	return evictCache(dir, max, blob)
+}
+
+// forgetDownload drops url's cache entry and partial download. The blob
+// stays until it is evicted.
+func forgetDownload(rawURL string) {
+	dir, err := CacheDir()
+	if err != nil {
+		return
+	}
+	_ = os.Remove(filepath.Join(dir, "urls", urlKey(rawURL)))
+	part := filepath.Join(dir, "partial", urlKey(rawURL))
+	dropPartial(part, part+".validator")
+}
+
+// maxPartialAge is how long an abandoned partial download is kept for a
+// later run to resume.
+const maxPartialAge = 7 * 24 * time.Hour
+
+const activePartial = time.Minute
+
+// evictCache drops partial downloads older than maxPartialAge, removes the
+// least recently used blobs and partial downloads until the cache fits in
+// max bytes, then every URL entry whose blob is gone. keep is never
+// removed.
+func evictCache(dir string, max int64, keep string) error {
+	type fileInfo struct {
+		path    string
+		size    int64
+		used    time.Time
+		partial bool
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	files := []fileInfo{}
+	var total int64
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || e.IsDir() || strings.Contains(e.Name(), ".tmp-") {
+			continue
+		}
+		files = append(files, fileInfo{path: filepath.Join(dir, "blobs", e.Name()), size: fi.Size(), used: fi.ModTime()})
+		total += fi.Size()
+	}
+	parts, _ := os.ReadDir(filepath.Join(dir, "partial"))
+	for _, e := range parts {
+		fi, err := e.Info()
+		if err != nil || e.IsDir() || strings.HasSuffix(e.Name(), ".validator") || strings.Contains(e.Name(), ".tmp-") {
+			continue
+		}
+		p := filepath.Join(dir, "partial", e.Name())
+		if time.Since(fi.ModTime()) > maxPartialAge {
+			dropPartial(p, p+".validator")
+			continue
+		}
+		files = append(files, fileInfo{path: p, size: fi.Size(), used: fi.ModTime(), partial: true})
+		total += fi.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	for _, f := range files {
+		if total <= max {
+			break
+		}
+		// A partial changed within activePartial may still be written to
+		// by a concurrent download.
+		if f.path == keep || (f.partial && time.Since(f.used) < activePartial) {
+			continue
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+			if f.partial {
+				_ = os.Remove(f.path + ".validator")
+			}
+		}
+	}
+
+	urls, _ := os.ReadDir(filepath.Join(dir, "urls"))
+	for _, e := range urls {
+		p := filepath.Join(dir, "urls", e.Name())
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		sum, _, _ := strings.Cut(string(b), "\n")
+		if _, err := os.Stat(filepath.Join(dir, "blobs", strings.TrimSpace(sum))); err != nil {
+			_ = os.Remove(p)
+		}
+	}
+	return nil
+}
+
+// CleanCache empties the download cache and the saved release metadata,
+// and returns the bytes freed.
+func CleanCache() (int64, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return 0, err
+	}
+	_, size, err := CacheSize()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, err
+	}
+	if p, err := releaseCachePath(""); err == nil {
+		_ = os.RemoveAll(filepath.Dir(p))
+	}
+	return size, nil
+}
+
+// CacheSize reports the number of cached files, partial downloads
+// included, and their total size.
+func CacheSize() (files int, bytes int64, err error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, sub := range []string{"blobs", "partial"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return 0, 0, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if fi, err := e.Info(); err == nil && !e.IsDir() && !strings.Contains(name, ".tmp-") && !strings.HasSuffix(name, ".validator") {
+				files++
+				bytes += fi.Size()
+			}
+		}
+	}
+	return files, bytes, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func copyFileAtomic(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_ = f.Close()
+	if err := copyFile(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package install
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://github.com/o/r/releases/download/v1.2.3/r-linux.tar.gz", true},
+		{"https://nodejs.org/dist/v20.11.0/node-v20.11.0-linux-x64.tar.gz", true},
+		{"http://mirror.example/o/r/releases/download/v1.2.3/r.zip", true},
+		{"https://github.com/neovim/neovim/releases/download/nightly/nvim.tar.gz", false},
+		{"https://github.com/neovim/neovim/releases/download/stable/nvim.tar.gz", false},
+		{"https://github.com/o/r/releases/latest/download/r.tar.gz", false},
+		{"file:///tmp/r.tar.gz", false},
+		{"::not a url", false},
+	}
+	for _, tt := range tests {
+		if got := cacheable(tt.url); got != tt.want {
+			t.Errorf("cacheable(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, data string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestStoreDownload(t *testing.T) {
+	const url = "https://github.com/o/r/releases/download/v1/r.tar.gz"
+	tests := []struct {
+		name    string
+		url     string
+		maxMB   string
+		damage  bool
+		wantHit bool
+	}{
+		{"stored", url, "", false, true},
+		{"moving tag", "https://github.com/o/r/releases/download/nightly/r.tar.gz", "", false, false},
+		{"cache off", url, "0", false, false},
+		{"damaged blob", url, "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			t.Setenv("NVIMWIZ_CACHE_MAX_MB", tt.maxMB)
+			if err := storeDownload(tt.url, writeTestFile(t, "archive bytes")); err != nil {
+				t.Fatal(err)
+			}
+			if tt.damage {
+				blob, ok := cachedDownload(tt.url)
+				if !ok {
+					t.Fatal("stored file not found")
+				}
+				if err := os.WriteFile(blob, []byte("archive bytez"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			blob, ok := cachedDownload(tt.url)
+			if ok != tt.wantHit {
+				t.Fatalf("cachedDownload hit = %v, want %v", ok, tt.wantHit)
+			}
+			if ok {
+				if b, _ := os.ReadFile(blob); string(b) != "archive bytes" {
+					t.Fatalf("cached content %q", b)
+				}
+			}
+			if tt.damage {
+				dir, _ := CacheDir()
+				if _, err := os.Stat(filepath.Join(dir, "urls", urlKey(tt.url))); !os.IsNotExist(err) {
+					t.Fatalf("URL entry of a damaged blob kept: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestEvictCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	put := func(rel string, size int, age time.Duration) string {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	oldest := put("blobs/aaa", 100, 5*time.Hour)
+	oldPart := put("partial/p1", 100, 4*time.Hour)
+	oldMeta := put("partial/p1.validator", 5, 4*time.Hour)
+	kept := put("blobs/bbb", 100, 3*time.Hour)
+	recent := put("blobs/ccc", 100, 2*time.Hour)
+	active := put("partial/p2", 100, time.Second)
+	stale := put("partial/p3", 10, 8*24*time.Hour)
+	for name, sum := range map[string]string{"u1": "aaa", "u2": "bbb"} {
+		if err := writeFileAtomic(filepath.Join(dir, "urls", name), []byte(sum+"\nhttps://x/"+sum+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 500 bytes live, 250 allowed: the blobs and partials go oldest first,
+	// except the kept blob and the partial still being written. The stale
+	// partial goes regardless of size.
+	if err := evictCache(dir, 250, kept); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{oldest, oldPart, oldMeta, recent, stale, filepath.Join(dir, "urls", "u1")} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s kept, want it evicted", filepath.Base(p))
+		}
+	}
+	for _, p := range []string{kept, active, filepath.Join(dir, "urls", "u2")} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s evicted: %v", filepath.Base(p), err)
+		}
+	}
+}
+
+func TestPartialPathsWithoutCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dst := filepath.Join(t.TempDir(), "r.tar.gz")
+	const url = "https://github.com/o/r/releases/download/v1/r.tar.gz"
+
+	dir, _ := CacheDir()
+	if part, _ := partialPaths(url, dst); filepath.Dir(part) != filepath.Join(dir, "partial") {
+		t.Errorf("partial at %s, want it in the cache", part)
+	}
+	t.Setenv("NVIMWIZ_CACHE_MAX_MB", "0")
+	if part, meta := partialPaths(url, dst); part != dst+".part" || meta != dst+".part.validator" {
+		t.Errorf("partialPaths with caching off = %s, %s", part, meta)
+	}
+}
+
+func TestDownloadFileCachesAfterCommit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte("archive bytes"))
+	}))
+	defer srv.Close()
+	r := remote{client: srv.Client()}
+	url := srv.URL + "/o/r/releases/download/v1/r.tar.gz"
+
+	fetch := func() {
+		t.Helper()
+		dst := filepath.Join(t.TempDir(), "r.tar.gz")
+		if err := downloadFile(context.Background(), r, url, dst, nil); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(dst); string(b) != "archive bytes" {
+			t.Fatalf("downloaded %q", b)
+		}
+	}
+	fetch()
+	if _, ok := cachedDownload(url); ok {
+		t.Fatal("download cached before it was committed")
+	}
+	fetch()
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("%d requests before commit, want 2", n)
+	}
+
+	dst := filepath.Join(t.TempDir(), "r.tar.gz")
+	if err := downloadFile(context.Background(), r, url, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	commitDownload(r, url, dst, nil)
+	fetch()
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Fatalf("%d requests, want the committed file served from the cache", n)
+	}
+}
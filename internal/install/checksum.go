@@ -3,32 +3,91 @@ package install
 import (
 	"bufio"
 	"os"
+	"path"
+	"regexp"
 	"strings"
 )
 
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// bsdChecksumLine matches "SHA256 (name) = hash" as written by shasum --tag
+// and BSD sha256.
+var bsdChecksumLine = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+
 func parseChecksumFile(path string) (map[string]string, error) {
-	f, err := os.Open(path)
+	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	return parseChecksums(string(b)), nil
+}
+
+// parseChecksums reads sha256 lines in the coreutils ("hash  name", "hash
+// *name") or BSD format. Names are keyed as written and by their base name,
+// so "./dist/x.tar.gz" also matches "x.tar.gz". A line holding only a hash
+// is keyed by "".
+func parseChecksums(text string) map[string]string {
 	m := map[string]string{}
-	s := bufio.NewScanner(f)
+	add := func(name, sum string) {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		m[name] = strings.ToLower(sum)
+		if base := path.Base(strings.TrimPrefix(name, "./")); base != name {
+			if _, ok := m[base]; !ok {
+				m[base] = strings.ToLower(sum)
+			}
+		}
+	}
+	s := bufio.NewScanner(strings.NewReader(text))
 	for s.Scan() {
 		line := strings.TrimSpace(s.Text())
-		if line == "" {
+		if sub := bsdChecksumLine.FindStringSubmatch(line); sub != nil {
+			add(sub[1], sub[2])
 			continue
 		}
 		fields := strings.Fields(line)
-		if len(fields) < 2 {
+		if len(fields) == 0 || !sha256Pattern.MatchString(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			m[""] = strings.ToLower(fields[0])
 			continue
 		}
-		h := strings.TrimSpace(fields[0])
-		name := strings.TrimSpace(fields[len(fields)-1])
-		m[name] = h
+		add(fields[len(fields)-1], fields[0])
 	}
-	if err := s.Err(); err != nil {
-		return nil, err
+	return m
+}
+
+// isChecksumList reports whether a release asset is a combined checksum file
+// covering several assets, such as SHA256SUMS, shasum.txt or goreleaser's
+// <project>_<version>_checksums.txt.
+func isChecksumList(name string) bool {
+	n := strings.ToLower(name)
+	switch strings.TrimSuffix(n, ".txt") {
+	case "sha256sums", "sha256sum", "sha256", "shasum", "shasums", "shasums256", "checksums", "checksums.sha256":
+		return true
+	}
+	for _, suffix := range []string{"_checksums.txt", "-checksums.txt", "_sha256sums.txt", "-sha256sums.txt", ".sha256sums"} {
+		if strings.HasSuffix(n, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// notesChecksums collects checksum lines from the fenced code blocks of a
+// release's notes.
+func notesChecksums(body string) map[string]string {
+	var block []string
+	in := false
+	for _, line := range strings.Split(body, "\n") {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			in = !in
+			continue
+		}
+		if in {
+			block = append(block, t)
+		}
 	}
-	return m, nil
+	return parseChecksums(strings.Join(block, "\n"))
 }
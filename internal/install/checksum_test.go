@@ -0,0 +1,171 @@
+package install
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nvimwiz/internal/events"
+)
+
+const (
+	sumA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	sumB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]string
+	}{
+		{"coreutils", sumA + "  a.tar.gz\n" + sumB + "  b.zip\n", map[string]string{"a.tar.gz": sumA, "b.zip": sumB}},
+		{"binary mode", sumA + " *a.tar.gz\n", map[string]string{"a.tar.gz": sumA}},
+		{"upper case", strings.ToUpper(sumA) + "  a.tar.gz\n", map[string]string{"a.tar.gz": sumA}},
+		{"bsd", "SHA256 (a.tar.gz) = " + sumA + "\n", map[string]string{"a.tar.gz": sumA}},
+		{"bsd with spaces", "SHA256 (my file.tar.gz) = " + sumA + "\n", map[string]string{"my file.tar.gz": sumA}},
+		{"paths", sumA + "  ./dist/a.tar.gz\n", map[string]string{"./dist/a.tar.gz": sumA, "a.tar.gz": sumA}},
+		{"hash only", sumA + "\n", map[string]string{"": sumA}},
+		{"crlf and junk", "# checksums\r\n" + sumA + "  a.tar.gz\r\nnot a hash  b.zip\r\n" + sumB[:40] + "  c.zip\r\n", map[string]string{"a.tar.gz": sumA}},
+		{"other algorithms", "SHA512 (a.tar.gz) = " + sumA + sumA + "\n", map[string]string{}},
+		// An exact entry wins over another file's base name.
+		{"base name clash", sumA + "  a.tar.gz\n" + sumB + "  old/a.tar.gz\n", map[string]string{"a.tar.gz": sumA, "old/a.tar.gz": sumB}},
+	}
+	for _, tt := range tests {
+		if got := parseChecksums(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseChecksums = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsChecksumList(t *testing.T) {
+	for name, want := range map[string]bool{
+		"SHA256SUMS":                   true,
+		"sha256sums.txt":               true,
+		"shasum.txt":                   true,
+		"checksums.txt":                true,
+		"checksums.sha256":             true,
+		"lazygit_0.44.1_checksums.txt": true,
+		"fzf_0.56.0-checksums.txt":     true,
+		"release.sha256sums":           true,
+		"nvim-linux64.tar.gz":          false,
+		"nvim-linux64.tar.gz.sha256":   false,
+		"checksums.txt.sig":            false,
+		"readme.txt":                   false,
+	} {
+		if got := isChecksumList(name); got != want {
+			t.Errorf("isChecksumList(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNotesChecksums(t *testing.T) {
+	body := "## Changes\n\n" + sumB + "  outside.tar.gz\n\n" +
+		"```\n" + sumA + "  a.tar.gz\n```\n\n" +
+		"~~~text\nSHA256 (b.zip) = " + sumB + "\n~~~\n"
+	want := map[string]string{"a.tar.gz": sumA, "b.zip": sumB}
+	if got := notesChecksums(body); !reflect.DeepEqual(got, want) {
+		t.Fatalf("notesChecksums = %v, want %v", got, want)
+	}
+}
+
+func TestChecksumSourcesOrder(t *testing.T) {
+	rel := ghRelease{
+		Body: "```\n" + sumA + "  a.tar.gz\n```",
+		Assets: []ghAsset{
+			{Name: "a.tar.gz"}, {Name: "SHA256SUMS"}, {Name: "a.tar.gz.sha256"}, {Name: "b.tar.gz.sha256"},
+		},
+	}
+	names := []string{}
+	for _, s := range checksumSources(rel, rel.Assets[0]) {
+		names = append(names, s.name)
+	}
+	if want := []string{"a.tar.gz.sha256", "SHA256SUMS", "release notes"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("sources %v, want %v", names, want)
+	}
+}
+
+func TestVerifyAssetIfPossible(t *testing.T) {
+	data := []byte("the asset")
+	h := sha256.Sum256(data)
+	good := hex.EncodeToString(h[:])
+
+	tests := []struct {
+		name    string
+		policy  string
+		files   map[string]string
+		body    string
+		source  string // the source reported as verified
+		wantErr string
+		skipped bool
+	}{
+		{"per-asset file", "auto", map[string]string{"a.tar.gz.sha256": good + "\n"}, "", "a.tar.gz.sha256", "", false},
+		{"per-asset named", "auto", map[string]string{"a.tar.gz.sha256": good + "  a.tar.gz\n"}, "", "a.tar.gz.sha256", "", false},
+		{"combined list", "require", map[string]string{"SHA256SUMS": sumB + "  b.zip\n" + good + "  a.tar.gz\n"}, "", "SHA256SUMS", "", false},
+		{"list without the asset falls through", "require", map[string]string{"SHA256SUMS": sumB + "  b.zip\n"}, "```\n" + good + "  a.tar.gz\n```", "release notes", "", false},
+		{"release notes", "require", nil, "Checksums:\n```\n" + good + "  a.tar.gz\n```", "release notes", "", false},
+		{"mismatch", "auto", map[string]string{"SHA256SUMS": sumA + "  a.tar.gz\n"}, "", "", "expected " + sumA + " from SHA256SUMS", false},
+		{"require without checksum", "require", nil, "", "", "checksum not available", false},
+		{"auto without checksum", "auto", nil, "", "", "", true},
+		{"off", "off", map[string]string{"SHA256SUMS": sumA + "  a.tar.gz\n"}, "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := tt.files[strings.TrimPrefix(r.URL.Path, "/")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, body)
+			}))
+			defer srv.Close()
+			rel := ghRelease{Body: tt.body, Assets: []ghAsset{{Name: "a.tar.gz"}}}
+			for n := range tt.files {
+				rel.Assets = append(rel.Assets, ghAsset{Name: n, BrowserDownloadURL: srv.URL + "/" + n})
+			}
+			path := filepath.Join(t.TempDir(), "a.tar.gz")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var verified, skipped []events.Event
+			emit := events.Sink(func(e events.Event) {
+				switch e.Kind {
+				case events.ChecksumVerified:
+					verified = append(verified, e)
+				case events.ChecksumSkipped:
+					skipped = append(skipped, e)
+				}
+			})
+			err := verifyAssetIfPossible(context.Background(), remote{client: srv.Client()}, tt.policy, nil, rel, rel.Assets[0], path, emit)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.source != "" && (len(verified) != 1 || verified[0].Source != tt.source) {
+				t.Fatalf("verified %+v, want source %s", verified, tt.source)
+			}
+			if tt.source == "" && len(verified) != 0 {
+				t.Fatalf("verified %+v, want nothing", verified)
+			}
+			if tt.skipped != (len(skipped) == 1) {
+				t.Fatalf("skipped %+v", skipped)
+			}
+		})
+	}
+}
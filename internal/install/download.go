@@ -2,22 +2,82 @@ package install
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"nvimwiz/internal/events"
 )
 
-func downloadFile(ctx context.Context, url, dst string) error {
-	const maxAttempts = 4
+// httpAttempts is how often a single HTTP request is tried before its
+// transient errors are returned to the task runner, which has its own
+// retry policy on top.
+const httpAttempts = 4
+
+// httpClient never limits the whole request, since archives can take a
+// while on slow links, but it does not wait forever for a server to answer.
+// The task timeout bounds the total through the request context.
+var httpClient = &http.Client{Transport: newTransport()}
+
+func newTransport() http.RoundTripper {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+	t = t.Clone()
+	t.ResponseHeaderTimeout = 30 * time.Second
+	t.TLSHandshakeTimeout = 15 * time.Second
+	return t
+}
+
+// downloadFile fetches url to dst, or copies it from the download cache
+// when an earlier run already fetched the same immutable URL. The URL is
+// rewritten per r's source, and its mirrors are tried when it fails. A
+// fetched file is not cached: the caller commits it with commitDownload
+// once it has checked the content.
+func downloadFile(ctx context.Context, r remote, url, dst string, emit events.Sink) error {
+	if blob, ok := cachedDownload(url); ok {
+		emit.Log("Using cached " + filepath.Base(dst))
+		return copyFile(blob, dst)
+	}
+	urls := r.downloadURLs(url)
+	var err error
+	for i, u := range urls {
+		if i > 0 {
+			emit.Warn(fmt.Sprintf("Download of %s failed (%v), trying mirror %s", filepath.Base(dst), err, u))
+		}
+		if err = fetchFile(ctx, r.client, u, dst, emit); err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	return err
+}
+
+func fetchFile(ctx context.Context, client *http.Client, url, dst string, emit events.Sink) error {
+	const maxAttempts = httpAttempts
 	var lastErr error
 
+	// The partial file is kept in the cache across attempts, calls and
+	// runs, so a task retry, a timeout or a resumed apply continues where
+	// the last try stopped. It goes once it is complete (renamed to dst)
+	// or the server no longer has the same file.
+	tmp, meta := partialPaths(url, dst)
+
+	// validator is the ETag or Last-Modified of the response the partial
+	// file came from; without one a resumed range could splice two
+	// different files together.
+	validator := loadPartialValidator(tmp, meta)
+
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if attempt > 1 {
+			emit.Warn(fmt.Sprintf("Download of %s failed (%v), retrying (%d/%d)", filepath.Base(dst), lastErr, attempt, maxAttempts))
 			// Exponential backoff: 1s, 2s, 4s...
 			backoff := time.Second * time.Duration(1<<(attempt-2))
 			select {
@@ -27,36 +87,74 @@ func downloadFile(ctx context.Context, url, dst string) error {
 			}
 		}
 
-		tmp := dst + ".part"
-		_ = os.Remove(tmp)
+		var offset int64
+		if fi, err := os.Stat(tmp); err == nil && validator != "" {
+			offset = fi.Size()
+		} else {
+			_ = os.Remove(tmp)
+		}
 
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
 			return err
 		}
 		req.Header.Set("User-Agent", "nvimwiz")
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", validator)
+		}
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 		func() {
 			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+				// The partial file is no prefix of what the server has now.
+				dropPartial(tmp, meta)
+				validator = ""
+				lastErr = &httpError{StatusCode: resp.StatusCode, Body: "cannot resume, restarting"}
+				return
+			}
 			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 				b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
 				lastErr = &httpError{StatusCode: resp.StatusCode, Body: string(b)}
 				return
 			}
 
-			f, err := os.Create(tmp)
+			flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			if resp.StatusCode == http.StatusPartialContent {
+				if offset == 0 || contentRangeStart(resp) != offset {
+					dropPartial(tmp, meta)
+					validator = ""
+					lastErr = errors.New("server sent an unexpected range, restarting")
+					return
+				}
+				flags = os.O_WRONLY | os.O_APPEND
+				emit.Log(fmt.Sprintf("Resuming %s at %s", filepath.Base(dst), events.FormatBytes(offset)))
+			} else {
+				// A full response: the server ignores ranges or the file
+				// changed since the partial download.
+				offset = 0
+				validator = responseValidator(resp)
+				savePartialValidator(meta, validator)
+			}
+
+			f, err := os.OpenFile(tmp, flags, 0o644)
 			if err != nil {
 				lastErr = err
 				return
 			}
 			defer f.Close()
 
-			if _, err := io.Copy(f, resp.Body); err != nil {
+			total := resp.ContentLength
+			if total >= 0 {
+				total += offset
+			}
+			pw := &progressWriter{emit: emit, file: filepath.Base(dst), total: total, n: offset}
+			if _, err := io.Copy(io.MultiWriter(f, pw), resp.Body); err != nil {
 				lastErr = err
 				return
 			}
@@ -69,11 +167,13 @@ func downloadFile(ctx context.Context, url, dst string) error {
 				return
 			}
 
-			_ = os.Remove(dst)
-			if err := os.Rename(tmp, dst); err != nil {
+			pw.report(true)
+
+			if err := moveFile(tmp, dst); err != nil {
 				lastErr = err
 				return
 			}
+			_ = os.Remove(meta)
 			lastErr = nil
 		}()
 
@@ -81,9 +181,10 @@ func downloadFile(ctx context.Context, url, dst string) error {
 			return nil
 		}
 
-		// Only retry on transient HTTP errors (5xx) and rate limiting (429).
+		// Only retry on transient HTTP errors (5xx), rate limiting (429)
+		// and a range that could not be resumed.
 		if he, ok := lastErr.(*httpError); ok {
-			if he.StatusCode < 500 && he.StatusCode != 429 {
+			if he.StatusCode < 500 && he.StatusCode != 429 && he.StatusCode != http.StatusRequestedRangeNotSatisfiable {
 				return lastErr
 			}
 		}
@@ -95,6 +196,151 @@ func downloadFile(ctx context.Context, url, dst string) error {
 	return lastErr
 }
 
+// partialPaths returns where the partial download of url and its validator
+// are kept: in the download cache, keyed by URL, or next to dst when there
+// is no cache dir or caching is turned off.
+func partialPaths(url, dst string) (part, meta string) {
+	part = dst + ".part"
+	if cacheMaxBytes() == 0 {
+		return part, part + ".validator"
+	}
+	if dir, err := CacheDir(); err == nil {
+		if err := os.MkdirAll(filepath.Join(dir, "partial"), 0o755); err == nil {
+			part = filepath.Join(dir, "partial", urlKey(url))
+		}
+	}
+	return part, part + ".validator"
+}
+
+// loadPartialValidator returns the validator of the partial file at part,
+// or "" after removing a partial file that cannot be resumed safely.
+func loadPartialValidator(part, meta string) string {
+	b, err := os.ReadFile(meta)
+	v := strings.TrimSpace(string(b))
+	if _, serr := os.Stat(part); err != nil || serr != nil || v == "" {
+		dropPartial(part, meta)
+		return ""
+	}
+	return v
+}
+
+func savePartialValidator(meta, v string) {
+	if v == "" {
+		_ = os.Remove(meta)
+		return
+	}
+	_ = writeFileAtomic(meta, []byte(v+"\n"))
+}
+
+func dropPartial(part, meta string) {
+	_ = os.Remove(part)
+	_ = os.Remove(meta)
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems.
+func moveFile(src, dst string) error {
+	_ = os.Remove(dst)
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// responseValidator returns the strong ETag or the Last-Modified date of
+// resp for a later If-Range, or "" when it has neither. Weak ETags cannot
+// be used with If-Range.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// contentRangeStart is the first byte of a 206 response ("bytes 100-199/200"),
+// or -1 when the header is missing or malformed.
+func contentRangeStart(resp *http.Response) int64 {
+	cr := strings.TrimSpace(resp.Header.Get("Content-Range"))
+	if !strings.HasPrefix(cr, "bytes ") {
+		return -1
+	}
+	first, _, ok := strings.Cut(strings.TrimPrefix(cr, "bytes "), "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// progressWriter emits throttled download progress events as bytes arrive.
+type progressWriter struct {
+	emit  events.Sink
+	file  string
+	total int64
+	n     int64
+	last  time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.n += int64(len(b))
+	p.report(false)
+	return len(b), nil
+}
+
+func (p *progressWriter) report(final bool) {
+	if p.emit == nil {
+		return
+	}
+	if !final && time.Since(p.last) < 200*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+	total := p.total
+	if total < 0 {
+		total = 0
+	}
+	p.emit.Emit(events.Event{Kind: events.DownloadProgress, File: p.file, Bytes: p.n, TotalBytes: total})
+}
+
+// IsTransient reports whether err is a network failure or a server-side
+// HTTP error (5xx, 429) that may succeed when tried again.
+func IsTransient(err error) bool {
+	if code := HTTPStatus(err); code != 0 {
+		return code >= 500 || code == 429
+	}
+	return IsNetworkError(err)
+}
+
+// HTTPStatus returns the status code of the HTTP error in err's chain, or 0
+// when there is none.
+func HTTPStatus(err error) int {
+	var he *httpError
+	if errors.As(err, &he) {
+		return he.StatusCode
+	}
+	return 0
+}
+
+// IsNetworkError reports whether err is a connection failure or a response
+// that broke off, rather than an error status from the server or the
+// caller's deadline.
+func IsNetworkError(err error) bool {
+	if err == nil || HTTPStatus(err) != 0 || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
 type httpError struct {
 	StatusCode int
 	Body       string
@@ -107,16 +353,3 @@ func (e *httpError) Error() string {
 	}
 	return fmt.Sprintf("http error (%d): %s", e.StatusCode, msg)
 }
-
-func sha256File(path string) (string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(h.Sum(nil)), nil
-}
@@ -0,0 +1,139 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFetchFileResumesAcrossCalls breaks a download off by cancelling the
+// first call, as a task timeout would, and checks that a later call asks
+// for the rest only.
+func TestFetchFileResumesAcrossCalls(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+	half := len(body) / 2
+
+	var mu sync.Mutex
+	ranges := []string{}
+	broke := make(chan struct{})
+	first := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		cut := first
+		first = false
+		mu.Unlock()
+		if cut {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", "10000")
+			w.WriteHeader(http.StatusOK)
+			w.Write(body[:half])
+			w.(http.Flusher).Flush()
+			close(broke)
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "a.tar.gz", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+
+	url := srv.URL + "/a.tar.gz"
+	dst := filepath.Join(t.TempDir(), "a.tar.gz")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { <-broke; time.Sleep(50 * time.Millisecond); cancel() }()
+	if err := fetchFile(ctx, srv.Client(), url, dst, nil); err == nil {
+		t.Fatal("first call succeeded, want it cut off")
+	}
+	part, _ := partialPaths(url, dst)
+	if fi, err := os.Stat(part); err != nil || fi.Size() != int64(half) {
+		t.Fatalf("partial file after cut: %v, %v", fi, err)
+	}
+
+	dst2 := filepath.Join(t.TempDir(), "a.tar.gz")
+	if err := fetchFile(context.Background(), srv.Client(), url, dst2, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(dst2)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("resumed file differs (%d bytes, %v)", len(got), err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if last := ranges[len(ranges)-1]; !strings.HasPrefix(last, "bytes=5000-") {
+		t.Fatalf("second call sent Range %q, want bytes=5000-", last)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Fatalf("partial file kept after completion: %v", err)
+	}
+}
+
+// TestFetchFileRestartsChangedFile checks that a partial file is not
+// spliced onto a file that changed on the server.
+func TestFetchFileRestartsChangedFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	body := []byte(strings.Repeat("new", 100))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "a.tar.gz", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+
+	url := srv.URL + "/a.tar.gz"
+	dst := filepath.Join(t.TempDir(), "a.tar.gz")
+	part, meta := partialPaths(url, dst)
+	if err := os.WriteFile(part, []byte("old-old-old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	savePartialValidator(meta, `"v1"`)
+
+	if err := fetchFile(context.Background(), srv.Client(), url, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, body) {
+		t.Fatalf("got %q, want the new file", got)
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	netErr := fmt.Errorf("get: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	tests := []struct {
+		err       error
+		status    int
+		network   bool
+		transient bool
+	}{
+		{fmt.Errorf("github api error: %w", &httpError{StatusCode: 503}), 503, false, true},
+		{fmt.Errorf("download: %w", &httpError{StatusCode: 429}), 429, false, true},
+		{&httpError{StatusCode: 404}, 404, false, false},
+		{netErr, 0, true, true},
+		{fmt.Errorf("body: %w", io.ErrUnexpectedEOF), 0, true, true},
+		{fmt.Errorf("attempt: %w", context.DeadlineExceeded), 0, false, false},
+		{errors.New("checksum mismatch"), 0, false, false},
+		{nil, 0, false, false},
+	}
+	for _, tt := range tests {
+		if got := HTTPStatus(tt.err); got != tt.status {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", tt.err, got, tt.status)
+		}
+		if got := IsNetworkError(tt.err); got != tt.network {
+			t.Errorf("IsNetworkError(%v) = %v, want %v", tt.err, got, tt.network)
+		}
+		if got := IsTransient(tt.err); got != tt.transient {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.transient)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nvimwiz/internal/events"
+)
+
+// Limits on what one archive may unpack to, so a broken or hostile archive
+// cannot fill the disk. The largest release nvimwiz installs, Node.js,
+// unpacks to about 200 MB in a few thousand files.
+var (
+	maxExtractBytes int64 = 2 << 30
+	maxExtractFiles       = 50000
+)
+
+// Reasons an archive is refused, wrapped in an *ExtractError.
+var (
+	ErrUnsafePath      = errors.New("entry path is absolute or leaves the install directory")
+	ErrUnsafeSymlink   = errors.New("symlink target is absolute or leaves the install directory")
+	ErrThroughSymlink  = errors.New("entry would be written through a symlink")
+	ErrArchiveTooLarge = errors.New("archive unpacks to more data than allowed")
+	ErrTooManyFiles    = errors.New("archive holds more files than allowed")
+)
+
+// ExtractError reports an archive entry that extraction refused. Nothing
+// from the archive is installed when it is returned.
+type ExtractError struct {
+	Archive string
+	Entry   string
+	Err     error
+}
+
+func (e *ExtractError) Error() string {
+	msg := filepath.Base(e.Archive) + ": " + e.Err.Error()
+	if e.Entry != "" {
+		msg += " (" + e.Entry + ")"
+	}
+	return msg
+}
+
+func (e *ExtractError) Unwrap() error { return e.Err }
+
+// Explain describes the refusal for the apply log.
+func (e *ExtractError) Explain() string {
+	switch {
+	case errors.Is(e.Err, ErrArchiveTooLarge), errors.Is(e.Err, ErrTooManyFiles):
+		return fmt.Sprintf("The archive was not unpacked because it exceeds nvimwiz's limits (%s, %d files). It is probably corrupt; the cached copy was dropped, so running again downloads it afresh.", events.FormatBytes(maxExtractBytes), maxExtractFiles)
+	default:
+		return "The archive was not unpacked because it tries to write outside its install directory. This usually means the download was tampered with; nothing from it was installed."
+	}
+}
+
+// Archive formats an asset can come in, by file name suffix.
+const (
+	archiveTarGz    = ".tar.gz"
+	archiveTarXz    = ".tar.xz"
+	archiveZip      = ".zip"
+	archiveGz       = ".gz"
+	archiveAppImage = ".appimage"
+	archiveNone     = ""
+)
+
+// archiveType returns the format of an asset from its name.
+func archiveType(name string) string {
+	n := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(n, ".tar.gz"), strings.HasSuffix(n, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(n, ".tar.xz"), strings.HasSuffix(n, ".txz"):
+		return archiveTarXz
+	case strings.HasSuffix(n, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(n, ".gz"):
+		return archiveGz
+	case strings.HasSuffix(n, ".appimage"):
+		return archiveAppImage
+	}
+	return archiveNone
+}
+
+// extractor unpacks one archive into dest under the rules every format
+// shares: entries may not leave dest, directly or through a symlink the
+// archive created, setuid, setgid and sticky bits are dropped, and the
+// total size and file count are capped.
+type extractor struct {
+	src, dest string
+	// top is the first entry's top-level path component.
+	top string
+	// links maps each symlink the archive created to its target.
+	links   map[string]string
+	files   int
+	written int64
+}
+
+func newExtractor(src, dest string) *extractor {
+	return &extractor{src: src, dest: dest, links: map[string]string{}}
+}
+
+func (x *extractor) refuse(entry string, err error) error {
+	return &ExtractError{Archive: x.src, Entry: entry, Err: err}
+}
+
+// entry checks an archive entry name and returns its path relative to
+// dest, or "" for the root entry.
+func (x *extractor) entry(raw string) (string, error) {
+	name, ok := entryName(raw)
+	if !ok {
+		return "", x.refuse(raw, ErrUnsafePath)
+	}
+	if name == "" {
+		return "", nil
+	}
+	if x.files++; x.files > maxExtractFiles {
+		return "", x.refuse("", ErrTooManyFiles)
+	}
+	if x.throughLink(name) {
+		return "", x.refuse(raw, ErrThroughSymlink)
+	}
+	if x.top == "" {
+		x.top, _, _ = strings.Cut(name, string(filepath.Separator))
+	}
+	return name, nil
+}
+
+func (x *extractor) dir(name string, mode os.FileMode) error {
+	return os.MkdirAll(filepath.Join(x.dest, name), mode.Perm()|0o700)
+}
+
+// file writes r to name. size is what the archive claims, or -1.
+func (x *extractor) file(name string, mode os.FileMode, size int64, r io.Reader) error {
+	if size > maxExtractBytes-x.written {
+		return x.refuse(name, ErrArchiveTooLarge)
+	}
+	target := filepath.Join(x.dest, name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(r, maxExtractBytes-x.written+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if x.written += n; x.written > maxExtractBytes {
+		return x.refuse(name, ErrArchiveTooLarge)
+	}
+	return nil
+}
+
+func (x *extractor) symlink(name, target string) error {
+	if !symlinkInside(name, target) {
+		return x.refuse(name+" -> "+target, ErrUnsafeSymlink)
+	}
+	// A new link can also redirect links made earlier whose targets pass
+	// through its path, so every link is resolved again.
+	x.links[name] = target
+	for l, t := range x.links {
+		hops := 0
+		if _, ok := x.resolve(filepath.Dir(l), t, &hops); !ok {
+			delete(x.links, name)
+			return x.refuse(name+" -> "+target, ErrUnsafeSymlink)
+		}
+	}
+	p := filepath.Join(x.dest, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	_ = os.Remove(p)
+	return os.Symlink(target, p)
+}
+
+// maxLinkHops bounds how many links one resolve follows, like the kernel's
+// limit on symlink loops.
+const maxLinkHops = 40
+
+// resolve follows target from dir, both relative to dest, one component at
+// a time through the links the archive created, the way the kernel would.
+// It returns the path reached and false when that leaves dest or follows
+// more than maxLinkHops links.
+func (x *extractor) resolve(dir, target string, hops *int) (string, bool) {
+	cur := []string{}
+	if dir != "." {
+		cur = strings.Split(dir, string(filepath.Separator))
+	}
+	for _, c := range strings.Split(filepath.ToSlash(target), "/") {
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			if len(cur) == 0 {
+				return "", false
+			}
+			cur = cur[:len(cur)-1]
+			continue
+		}
+		cur = append(cur, c)
+		p := filepath.Join(cur...)
+		t, ok := x.links[p]
+		if !ok {
+			continue
+		}
+		if *hops++; *hops > maxLinkHops || filepath.IsAbs(t) || strings.HasPrefix(t, "/") {
+			return "", false
+		}
+		r, ok := x.resolve(filepath.Dir(p), t, hops)
+		if !ok {
+			return "", false
+		}
+		cur = cur[:0]
+		if r != "." {
+			cur = strings.Split(r, string(filepath.Separator))
+		}
+	}
+	if len(cur) == 0 {
+		return ".", true
+	}
+	return filepath.Join(cur...), true
+}
+
+// throughLink reports whether name, or one of its parent directories, is a
+// symlink the archive created earlier.
+func (x *extractor) throughLink(name string) bool {
+	for p := name; p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+		if _, ok := x.links[p]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// entryName cleans an archive entry name into a path relative to the
+// destination. It returns "" for the root entry and false for names that
+// are absolute or leave the destination.
+func entryName(raw string) (string, bool) {
+	raw = strings.ReplaceAll(raw, "\\", "/")
+	if strings.HasPrefix(raw, "/") || filepath.VolumeName(raw) != "" {
+		return "", false
+	}
+	name := filepath.Clean(filepath.FromSlash(raw))
+	if name == "." {
+		return "", true
+	}
+	if name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return name, true
+}
+
+// symlinkInside reports whether a symlink at name pointing to target stays
+// within the destination.
+func symlinkInside(name, target string) bool {
+	if target == "" || strings.HasPrefix(target, "/") || filepath.IsAbs(target) {
+		return false
+	}
+	_, ok := entryName(filepath.Join(filepath.Dir(name), filepath.FromSlash(target)))
+	return ok
+}
@@ -0,0 +1,245 @@
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testEntry struct {
+	name string
+	link string // symlink target; empty for files and dirs
+	body string
+	dir  bool
+	mode int64
+}
+
+func writeTarGz(t *testing.T, entries []testEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "a.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: e.mode}
+		switch {
+		case e.dir:
+			h.Typeflag = tar.TypeDir
+		case e.link != "":
+			h.Typeflag, h.Linkname = tar.TypeSymlink, e.link
+		default:
+			h.Typeflag, h.Size = tar.TypeReg, int64(len(e.body))
+		}
+		if h.Mode == 0 {
+			h.Mode = 0o644
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeZip(t *testing.T, entries []testEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "a.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.link != "" {
+			h.SetMode(os.ModeSymlink | 0o777)
+			body = e.link
+		} else {
+			h.SetMode(0o644)
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractRefusesUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		want    error
+	}{
+		{"dotdot", []testEntry{{name: "../evil", body: "x"}}, ErrUnsafePath},
+		{"nested dotdot", []testEntry{{name: "pkg/../../evil", body: "x"}}, ErrUnsafePath},
+		{"absolute", []testEntry{{name: "/tmp/evil", body: "x"}}, ErrUnsafePath},
+		{"absolute link", []testEntry{{name: "pkg/l", link: "/etc/passwd"}}, ErrUnsafeSymlink},
+		{"escaping link", []testEntry{{name: "pkg/l", link: "../../etc"}}, ErrUnsafeSymlink},
+		{"write through link", []testEntry{
+			{name: "pkg/l", link: "sub"},
+			{name: "pkg/l/f", body: "x"},
+		}, ErrThroughSymlink},
+		{"link through earlier link", []testEntry{
+			{name: "sub/d", link: ".."},
+			{name: "g", link: "sub/d/../.."},
+		}, ErrUnsafeSymlink},
+		{"earlier link redirected by later link", []testEntry{
+			{name: "g", link: "sub/d/../.."},
+			{name: "sub/d", link: ".."},
+		}, ErrUnsafeSymlink},
+		{"link chain out", []testEntry{
+			{name: "a/b/c", link: "../.."},
+			{name: "a/up", link: "b/c/.."},
+		}, ErrUnsafeSymlink},
+		{"link loop", []testEntry{
+			{name: "p", link: "q"},
+			{name: "q", link: "p"},
+		}, ErrUnsafeSymlink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := writeTarGz(t, tt.entries)
+			dest := t.TempDir()
+			_, err := extractTarGz(context.Background(), src, dest)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			var xe *ExtractError
+			if !errors.As(err, &xe) {
+				t.Fatalf("err = %T, want *ExtractError", err)
+			}
+		})
+	}
+}
+
+func TestExtractAllowsLinksInside(t *testing.T) {
+	src := writeTarGz(t, []testEntry{
+		{name: "pkg/", dir: true, mode: 0o755},
+		{name: "pkg/lib/libfoo.so.1.2", body: "lib"},
+		{name: "pkg/lib/libfoo.so.1", link: "libfoo.so.1.2"},
+		{name: "pkg/lib/libfoo.so", link: "libfoo.so.1"},
+		{name: "pkg/current", link: "lib"},
+		{name: "pkg/bin/foo", link: "../current/libfoo.so"},
+	})
+	dest := t.TempDir()
+	top, err := extractTarGz(context.Background(), src, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top != "pkg" {
+		t.Fatalf("top = %q, want pkg", top)
+	}
+	b, err := os.ReadFile(filepath.Join(dest, "pkg", "bin", "foo"))
+	if err != nil || string(b) != "lib" {
+		t.Fatalf("read through links = %q, %v", b, err)
+	}
+}
+
+func TestExtractStripsSpecialBits(t *testing.T) {
+	src := writeTarGz(t, []testEntry{{name: "pkg/bin/tool", body: "x", mode: 0o4755 | 0o2000 | 0o1000}})
+	dest := t.TempDir()
+	if _, err := extractTarGz(context.Background(), src, dest); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(filepath.Join(dest, "pkg", "bin", "tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) != 0 {
+		t.Fatalf("mode = %v, special bits kept", fi.Mode())
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	oldBytes, oldFiles := maxExtractBytes, maxExtractFiles
+	t.Cleanup(func() { maxExtractBytes, maxExtractFiles = oldBytes, oldFiles })
+	maxExtractBytes, maxExtractFiles = 16, 3
+
+	big := writeTarGz(t, []testEntry{{name: "pkg/a", body: "0123456789"}, {name: "pkg/b", body: "0123456789"}})
+	if _, err := extractTarGz(context.Background(), big, t.TempDir()); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("oversized: err = %v", err)
+	}
+	many := writeTarGz(t, []testEntry{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}})
+	if _, err := extractTarGz(context.Background(), many, t.TempDir()); !errors.Is(err, ErrTooManyFiles) {
+		t.Fatalf("too many files: err = %v", err)
+	}
+	zbig := writeZip(t, []testEntry{{name: "pkg/a", body: "01234567890123456789"}})
+	if _, err := extractZip(context.Background(), zbig, t.TempDir()); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("oversized zip: err = %v", err)
+	}
+}
+
+func TestExtractZipRefusesUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		want    error
+	}{
+		{"dotdot", []testEntry{{name: "../evil", body: "x"}}, ErrUnsafePath},
+		{"backslash dotdot", []testEntry{{name: `pkg\..\..\evil`, body: "x"}}, ErrUnsafePath},
+		{"escaping link", []testEntry{{name: "pkg/l", link: "../../x"}}, ErrUnsafeSymlink},
+		{"link through earlier link", []testEntry{
+			{name: "sub/d", link: ".."},
+			{name: "g", link: "sub/d/../.."},
+		}, ErrUnsafeSymlink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := writeZip(t, tt.entries)
+			if _, err := extractZip(context.Background(), src, t.TempDir()); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryName(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"pkg/bin/x", filepath.Join("pkg", "bin", "x"), true},
+		{"./pkg/", "pkg", true},
+		{"./", "", true},
+		{"pkg/../x", "x", true},
+		{"..", "", false},
+		{"../x", "", false},
+		{"/abs", "", false},
+		{`\abs`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := entryName(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("entryName(%q) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -28,7 +28,21 @@ func findFile(root, name string) (string, error) {
 	return found, nil
 }
 
+// replaceSymlink points linkPath at target by renaming a fresh symlink over
+// it, so the link is never missing while it is being switched.
 func replaceSymlink(linkPath, target string) error {
-	_ = os.Remove(linkPath)
-	return os.Symlink(target, linkPath)
+	tmp := linkPath + ".nvimwiz-tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, linkPath); err != nil {
+		_ = os.Remove(tmp)
+		if fi, lerr := os.Lstat(linkPath); lerr == nil && fi.IsDir() {
+			return err
+		}
+		_ = os.Remove(linkPath)
+		return os.Symlink(target, linkPath)
+	}
+	return nil
 }
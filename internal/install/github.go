@@ -5,8 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
+
+	"nvimwiz/internal/env"
 )
 
 type ghAsset struct {
@@ -16,14 +24,49 @@ type ghAsset struct {
 
 type ghRelease struct {
 	TagName string    `json:"tag_name"`
+	Name    string    `json:"name"`
 	Body    string    `json:"body"`
 	Assets  []ghAsset `json:"assets"`
 }
 
-func fetchLatestRelease(ctx context.Context, owner, repo string) (ghRelease, error) {
-	url := "https://api.github.com/repos/" + owner + "/" + repo + "/releases/latest"
+// fetchRelease resolves a version spec to a release: "" or "latest" is the
+// newest release, anything else ("stable", "nightly", "v0.10.2") is looked
+// up by tag. A pinned tag is also tried with its "v" prefix added or
+// removed, since projects differ on whether they use one.
+func fetchRelease(ctx context.Context, r remote, owner, repo, spec string) (ghRelease, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "latest") {
+		return fetchLatestRelease(ctx, r, owner, repo)
+	}
+	rel, err := fetchReleaseByTag(ctx, r, owner, repo, spec)
+	var he *httpError
+	if err == nil || !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+		return rel, err
+	}
+	alt := "v" + spec
+	if strings.HasPrefix(spec, "v") {
+		alt = strings.TrimPrefix(spec, "v")
+	}
+	if rel, err2 := fetchReleaseByTag(ctx, r, owner, repo, alt); err2 == nil {
+		return rel, nil
+	}
+	return ghRelease{}, fmt.Errorf("release %s not found for %s/%s", spec, owner, repo)
+}
+
+func fetchLatestRelease(ctx context.Context, r remote, owner, repo string) (ghRelease, error) {
+	return fetchReleaseURL(ctx, r, r.apiBase()+"/repos/"+owner+"/"+repo+"/releases/latest")
+}
+
+func fetchReleaseByTag(ctx context.Context, r remote, owner, repo, tag string) (ghRelease, error) {
+	return fetchReleaseURL(ctx, r, r.apiBase()+"/repos/"+owner+"/"+repo+"/releases/tags/"+url.PathEscape(tag))
+}
 
-	const maxAttempts = 4
+func fetchReleaseURL(ctx context.Context, r remote, endpoint string) (ghRelease, error) {
+	base := r.apiBase()
+	token, tokenFrom := r.apiToken()
+	cached, hasCached := loadCachedRelease(endpoint)
+
+	const maxAttempts = httpAttempts
 	var lastErr error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if attempt > 1 {
@@ -34,25 +77,59 @@ func fetchLatestRelease(ctx context.Context, owner, repo string) (ghRelease, err
 				return ghRelease{}, ctx.Err()
 			}
 		}
+		if e := rateLimited(base); e != nil {
+			wait := time.Until(e.Reset)
+			if wait > maxRateLimitWait {
+				return ghRelease{}, e
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ghRelease{}, ctx.Err()
+			}
+		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 		if err != nil {
 			return ghRelease{}, err
 		}
 		req.Header.Set("Accept", "application/vnd.github+json")
 		req.Header.Set("User-Agent", "nvimwiz")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		// A 304 answer costs no rate limit.
+		if hasCached && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := r.client.Do(req)
 		if err != nil {
 			lastErr = err
 			continue
 		}
+		limited := noteRateLimit(base, resp, token != "")
 
+		if resp.StatusCode == http.StatusNotModified && hasCached {
+			_ = resp.Body.Close()
+			return cached.Release, nil
+		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+			if limited != nil {
+				lastErr = limited
+				if !limited.Reset.IsZero() && time.Until(limited.Reset) <= maxRateLimitWait {
+					continue
+				}
+				return ghRelease{}, limited
+			}
+			if resp.StatusCode == http.StatusUnauthorized && token != "" {
+				return ghRelease{}, errors.New("github api error: the token in " + tokenFrom + " was rejected by " + base)
+			}
 			// Retry transient errors.
 			retryable := resp.StatusCode >= 500 || resp.StatusCode == 429
-			_ = resp.Body.Close()
-			lastErr = fmt.Errorf("github api error: status %d", resp.StatusCode)
+			lastErr = fmt.Errorf("github api error: %w", &httpError{StatusCode: resp.StatusCode, Body: githubMessage(b)})
 			if retryable {
 				continue
 			}
@@ -66,6 +143,9 @@ func fetchLatestRelease(ctx context.Context, owner, repo string) (ghRelease, err
 			lastErr = err
 			continue
 		}
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			storeCachedRelease(endpoint, cachedRelease{ETag: etag, Release: rel})
+		}
 		return rel, nil
 	}
 	if lastErr == nil {
@@ -74,6 +154,60 @@ func fetchLatestRelease(ctx context.Context, owner, repo string) (ghRelease, err
 	return ghRelease{}, lastErr
 }
 
+// githubMessage pulls "message" out of a GitHub API error body.
+func githubMessage(body []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		return e.Message
+	}
+	return ""
+}
+
+// cachedRelease is release metadata kept with its ETag for conditional
+// requests.
+type cachedRelease struct {
+	ETag    string    `json:"etag"`
+	Release ghRelease `json:"release"`
+}
+
+func releaseCachePath(endpoint string) (string, error) {
+	d, err := env.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "releases", urlKey(endpoint)+".json"), nil
+}
+
+func loadCachedRelease(endpoint string) (cachedRelease, bool) {
+	p, err := releaseCachePath(endpoint)
+	if err != nil {
+		return cachedRelease{}, false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return cachedRelease{}, false
+	}
+	var c cachedRelease
+	if err := json.Unmarshal(b, &c); err != nil || c.ETag == "" {
+		return cachedRelease{}, false
+	}
+	return c, true
+}
+
+func storeCachedRelease(endpoint string, c cachedRelease) {
+	p, err := releaseCachePath(endpoint)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(p, b)
+}
+
 func findAsset(rel ghRelease, fn func(a ghAsset) bool) (ghAsset, bool) {
 	for _, a := range rel.Assets {
 		if fn(a) {
@@ -82,3 +216,31 @@ func findAsset(rel ghRelease, fn func(a ghAsset) bool) (ghAsset, bool) {
 	}
 	return ghAsset{}, false
 }
+
+var versionPattern = regexp.MustCompile(`v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.+-]+)?)`)
+
+// releaseVersion returns the version a release installs. Moving tags such as
+// "stable" or "nightly" are resolved through the release name or notes.
+func releaseVersion(rel ghRelease) string {
+	if v := normalizeVersion(rel.TagName); v != "" && v[0] >= '0' && v[0] <= '9' {
+		return v
+	}
+	for _, s := range []string{rel.Name, rel.Body} {
+		if m := versionPattern.FindStringSubmatch(s); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// releaseDirName is the directory name a release is unpacked into. Moving
+// tags get the concrete version so each build keeps its own directory.
+func releaseDirName(rel ghRelease) string {
+	if v := normalizeVersion(rel.TagName); v != "" && v[0] >= '0' && v[0] <= '9' {
+		return rel.TagName
+	}
+	if v := releaseVersion(rel); v != "" {
+		return "v" + v
+	}
+	return rel.TagName
+}
@@ -0,0 +1,216 @@
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI serves release metadata through handle and records the requests
+// it saw.
+type fakeAPI struct {
+	srv    *httptest.Server
+	mu     sync.Mutex
+	seen   []*http.Request
+	handle func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func newFakeAPI(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, n int)) *fakeAPI {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	f := &fakeAPI{handle: handle}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.seen = append(f.seen, r.Clone(context.Background()))
+		n := len(f.seen)
+		f.mu.Unlock()
+		f.handle(w, r, n)
+	}))
+	t.Cleanup(f.srv.Close)
+	t.Cleanup(func() {
+		apiLimits.Lock()
+		delete(apiLimits.m, f.srv.URL)
+		apiLimits.Unlock()
+	})
+	return f
+}
+
+func (f *fakeAPI) remote() remote {
+	return remote{client: f.srv.Client(), src: Source{APIBase: f.srv.URL}}
+}
+
+func (f *fakeAPI) requests() []*http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*http.Request{}, f.seen...)
+}
+
+func writeRelease(w http.ResponseWriter, tag string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ghRelease{TagName: tag})
+}
+
+func TestNoteRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	resetHeader := strconv.FormatInt(reset.Unix(), 10)
+	tests := []struct {
+		name      string
+		status    int
+		headers   map[string]string
+		refused   bool
+		remembers bool
+	}{
+		{"ok", 200, map[string]string{"X-RateLimit-Remaining": "59", "X-RateLimit-Reset": resetHeader}, false, false},
+		{"last request", 200, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": resetHeader}, false, true},
+		{"exhausted", 403, map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": resetHeader}, true, true},
+		{"exhausted 429", 429, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": resetHeader}, true, true},
+		{"secondary limit", 403, map[string]string{"Retry-After": "30"}, true, true},
+		{"forbidden", 403, map[string]string{"X-RateLimit-Remaining": "12", "X-RateLimit-Reset": resetHeader}, false, false},
+		{"no headers", 403, nil, false, false},
+	}
+	for _, tt := range tests {
+		base := "https://api.example/" + tt.name
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		for k, v := range tt.headers {
+			resp.Header.Set(k, v)
+		}
+		e := noteRateLimit(base, resp, false)
+		if (e != nil) != tt.refused {
+			t.Errorf("%s: noteRateLimit = %v, want refused %v", tt.name, e, tt.refused)
+		}
+		if got := rateLimited(base) != nil; got != tt.remembers {
+			t.Errorf("%s: limit remembered = %v, want %v", tt.name, got, tt.remembers)
+		}
+		if e != nil && tt.headers["X-RateLimit-Reset"] != "" && !e.Reset.Equal(reset) {
+			t.Errorf("%s: Reset = %v, want %v", tt.name, e.Reset, reset)
+		}
+		apiLimits.Lock()
+		delete(apiLimits.m, base)
+		apiLimits.Unlock()
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	e := &RateLimitError{Limit: 60, Reset: time.Now().Add(10 * time.Minute)}
+	msg := e.Error()
+	for _, want := range []string{"(60 requests/hour)", "resets at", "Set GITHUB_TOKEN"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("%q lacks %q", msg, want)
+		}
+	}
+	e.Authenticated = true
+	if strings.Contains(e.Error(), "GITHUB_TOKEN") {
+		t.Errorf("authenticated error suggests a token: %q", e.Error())
+	}
+}
+
+func TestFetchReleaseReusesETag(t *testing.T) {
+	api := newFakeAPI(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		writeRelease(w, "v1.0.0")
+	})
+	r := api.remote()
+	for i := 0; i < 2; i++ {
+		rel, err := fetchRelease(context.Background(), r, "o", "r", "")
+		if err != nil || rel.TagName != "v1.0.0" {
+			t.Fatalf("fetch %d: %q, %v", i+1, rel.TagName, err)
+		}
+	}
+	seen := api.requests()
+	if len(seen) != 2 || seen[0].Header.Get("If-None-Match") != "" || seen[1].Header.Get("If-None-Match") != `"v1"` {
+		t.Fatalf("requests did not revalidate with the ETag: %d sent", len(seen))
+	}
+}
+
+func TestFetchReleaseRateLimited(t *testing.T) {
+	t.Run("reset far away", func(t *testing.T) {
+		reset := time.Now().Add(time.Hour).Unix()
+		api := newFakeAPI(t, func(w http.ResponseWriter, r *http.Request, n int) {
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+			w.WriteHeader(http.StatusForbidden)
+		})
+		r := api.remote()
+		var e *RateLimitError
+		if _, err := fetchRelease(context.Background(), r, "o", "r", ""); !errors.As(err, &e) || e.Limit != 60 {
+			t.Fatalf("err = %v, want *RateLimitError", err)
+		}
+		// The limit is remembered: the next lookup fails without a request.
+		if _, err := fetchRelease(context.Background(), r, "o", "other", ""); !errors.As(err, &e) {
+			t.Fatalf("second lookup: err = %v", err)
+		}
+		if n := len(api.requests()); n != 1 {
+			t.Fatalf("%d requests, want 1", n)
+		}
+	})
+
+	t.Run("reset soon", func(t *testing.T) {
+		api := newFakeAPI(t, func(w http.ResponseWriter, r *http.Request, n int) {
+			if n == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			writeRelease(w, "v1.0.0")
+		})
+		start := time.Now()
+		rel, err := fetchRelease(context.Background(), api.remote(), "o", "r", "")
+		if err != nil || rel.TagName != "v1.0.0" {
+			t.Fatalf("fetchRelease = %q, %v; want it to wait for the reset", rel.TagName, err)
+		}
+		if d := time.Since(start); d < time.Second {
+			t.Fatalf("retried after %s, before the limit reset", d)
+		}
+	})
+}
+
+// redirectTransport sends every request to srv, whatever its host.
+type redirectTransport struct{ srv *httptest.Server }
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(rt.srv.URL)
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return rt.srv.Client().Transport.RoundTrip(req)
+}
+
+func TestFetchReleaseToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "gh-secret")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("NVIMWIZ_GITHUB_API_TOKEN", "")
+	t.Setenv("GHE_TOKEN", "ghe-secret")
+	api := newFakeAPI(t, func(w http.ResponseWriter, r *http.Request, n int) {
+		writeRelease(w, "v1.0.0")
+	})
+	tests := []struct {
+		name string
+		r    remote
+		want string
+	}{
+		{"github.com", remote{client: &http.Client{Transport: redirectTransport{api.srv}}}, "Bearer gh-secret"},
+		{"other API base", api.remote(), ""},
+		{"other API base with tokenEnv", remote{client: api.srv.Client(), src: Source{APIBase: api.srv.URL, TokenEnv: "GHE_TOKEN"}}, "Bearer ghe-secret"},
+	}
+	for i, tt := range tests {
+		if _, err := fetchRelease(context.Background(), tt.r, "o", "r"+strconv.Itoa(i), ""); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		seen := api.requests()
+		if got := seen[len(seen)-1].Header.Get("Authorization"); got != tt.want {
+			t.Errorf("%s: Authorization = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
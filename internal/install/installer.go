@@ -0,0 +1,247 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// Install installs or updates the tool registered under name and returns
+// the path of its command in ~/.local/bin.
+func Install(ctx context.Context, name string, opts Options, emit events.Sink) (string, error) {
+	t, ok := LookupTool(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+	return InstallTool(ctx, t, opts, emit)
+}
+
+// InstallTool installs t from its GitHub releases. The download is skipped
+// when the installed command already reports the wanted version.
+func InstallTool(ctx context.Context, t ToolSpec, opts Options, emit events.Sink) (string, error) {
+	rel, err := fetchRelease(ctx, opts.remote(), t.Owner, t.Repo, t.releaseSpec(opts.Version))
+	if err != nil {
+		return "", err
+	}
+
+	lb, err := env.LocalBin()
+	if err != nil {
+		return "", err
+	}
+	dst := filepath.Join(lb, t.ExeName())
+
+	want := t.releaseVersion(rel)
+	cur := installedStatus(t.Name, t.ExeName(), func() (string, string, bool) { return t.installedVersion(ctx) })
+	if cur.CurrentOK && want != "" && cur.CurrentVersion == want {
+		emit.Log(t.Title + " already up to date (" + rel.TagName + "), skipping download")
+		if !cur.Managed {
+			adopt(t, want, cur.Path, dst, emit)
+		}
+		return cur.Path, nil
+	}
+
+	if err := os.MkdirAll(lb, 0o755); err != nil {
+		return "", err
+	}
+
+	var f fetched
+	paths := []string{dst}
+	switch t.Style {
+	case SymlinkDir, AppImage:
+		var targetDir string
+		targetDir, f, err = installDirRelease(ctx, t, rel, opts, emit)
+		if err != nil {
+			return "", err
+		}
+		bin := filepath.Join(targetDir, t.BinDir, t.ExeName())
+		if err := replaceSymlink(dst, bin); err != nil {
+			return "", err
+		}
+		paths = append(paths, targetDir)
+		if t.Name == "neovim" {
+			record(receipt.Receipt{Kind: receipt.Neovim, Name: filepath.Base(targetDir), Version: want, Source: f.URL, SHA256: f.SHA256, Paths: []string{targetDir}}, emit)
+		}
+		emit.Log("Installed " + t.Binary + " to " + bin)
+	default:
+		tmpDir, err := os.MkdirTemp("", "nvimwiz-"+t.Binary+"-*")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		extractDir := filepath.Join(tmpDir, "x")
+		f, err = fetchAndExtract(ctx, t, rel, opts, tmpDir, extractDir, emit)
+		if err != nil {
+			return "", err
+		}
+		binPath, err := findFile(extractDir, t.ExeName())
+		if err != nil {
+			return "", err
+		}
+		if err := copyFile(binPath, dst); err != nil {
+			return "", err
+		}
+		// Zip archives do not always carry the executable bit.
+		if err := os.Chmod(dst, 0o755); err != nil {
+			return "", err
+		}
+		emit.Log("Installed " + t.Binary + " to " + dst)
+	}
+	record(receipt.Receipt{Kind: receipt.Tool, Name: t.Name, Version: want, Source: f.URL, SHA256: f.SHA256, Paths: paths}, emit)
+	return dst, nil
+}
+
+// fetched describes an asset fetchAndExtract downloaded and unpacked.
+type fetched struct {
+	// Top is the archive's top-level entry; for single-binary assets that
+	// is the binary itself.
+	Top    string
+	URL    string
+	SHA256 string
+}
+
+// fetchAndExtract downloads t's asset from rel into dlDir, verifies it and
+// unpacks it into extractDir.
+func fetchAndExtract(ctx context.Context, t ToolSpec, rel ghRelease, opts Options, dlDir, extractDir string, emit events.Sink) (fetched, error) {
+	asset, note, err := t.pickAsset(rel)
+	if err != nil {
+		return fetched{}, err
+	}
+	if note != "" {
+		emit.Log("Using " + asset.Name + ": " + note)
+	}
+
+	keys := t.signingKeys(opts)
+	if opts.signed() && len(keys) == 0 {
+		return fetched{}, fmt.Errorf("verify is \"signed\" but no signing key is pinned for %s/%s; add one under signingKeys in the profile", t.Owner, t.Repo)
+	}
+
+	r := opts.remote()
+	archivePath := filepath.Join(dlDir, asset.Name)
+	emit.Log("Downloading " + asset.Name)
+	if err := downloadFile(ctx, r, asset.BrowserDownloadURL, archivePath, emit); err != nil {
+		return fetched{}, err
+	}
+	if err := verifyAssetIfPossible(ctx, r, opts.Verify, keys, rel, asset, archivePath, emit); err != nil {
+		return fetched{}, err
+	}
+	sum, err := receipt.FileSHA256(archivePath)
+	if err != nil {
+		return fetched{}, err
+	}
+
+	if err := os.MkdirAll(extractDir, 0o755); err != nil {
+		return fetched{}, err
+	}
+	top, err := unpackAsset(ctx, t, asset.Name, archivePath, extractDir)
+	if err != nil {
+		return fetched{}, err
+	}
+	commitDownload(r, asset.BrowserDownloadURL, archivePath, emit)
+	return fetched{Top: top, URL: asset.BrowserDownloadURL, SHA256: sum}, nil
+}
+
+// unpackAsset unpacks the downloaded asset name at archivePath into
+// extractDir and returns the path of its top-level entry.
+func unpackAsset(ctx context.Context, t ToolSpec, name, archivePath, extractDir string) (string, error) {
+	var top string
+	var err error
+	switch archiveType(name) {
+	case archiveTarGz:
+		top, err = extractTarGz(ctx, archivePath, extractDir)
+	case archiveTarXz:
+		top, err = extractTarXz(ctx, archivePath, extractDir)
+	case archiveZip:
+		top, err = extractZip(ctx, archivePath, extractDir)
+	case archiveGz:
+		dst := filepath.Join(extractDir, t.ExeName())
+		return dst, gunzipFile(archivePath, dst)
+	case archiveAppImage:
+		// The AppImage is the release: lay it out like an unpacked
+		// release dir so the binary sits at <BinDir>/<Binary>.
+		dst := filepath.Join(extractDir, t.BinDir, t.ExeName())
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return "", err
+		}
+		if err := copyFile(archivePath, dst); err != nil {
+			return "", err
+		}
+		return extractDir, os.Chmod(dst, 0o755)
+	default:
+		dst := filepath.Join(extractDir, t.ExeName())
+		if err := copyFile(archivePath, dst); err != nil {
+			return "", err
+		}
+		return dst, os.Chmod(dst, 0o755)
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(extractDir, top), nil
+}
+
+// installDirRelease downloads, verifies and unpacks rel into its own
+// directory under ~/.local/<DirRoot> and returns that directory.
+func installDirRelease(ctx context.Context, t ToolSpec, rel ghRelease, opts Options, emit events.Sink) (string, fetched, error) {
+	workRoot, err := toolDirRoot(t)
+	if err != nil {
+		return "", fetched{}, err
+	}
+	if err := os.MkdirAll(workRoot, 0o755); err != nil {
+		return "", fetched{}, err
+	}
+	removeStaleExtractDirs(workRoot)
+
+	tmpDir, err := os.MkdirTemp("", "nvimwiz-"+t.Binary+"-*")
+	if err != nil {
+		return "", fetched{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	extractTmp := filepath.Join(workRoot, ".tmp-"+time.Now().Format("20060102-150405"))
+	defer os.RemoveAll(extractTmp)
+	f, err := fetchAndExtract(ctx, t, rel, opts, tmpDir, extractTmp, emit)
+	if err != nil {
+		return "", fetched{}, err
+	}
+	if _, err := os.Stat(f.Top); err != nil {
+		return "", fetched{}, errors.New("extraction failed")
+	}
+
+	targetDir := filepath.Join(workRoot, releaseDirName(rel))
+	_ = os.RemoveAll(targetDir)
+	if err := os.Rename(f.Top, targetDir); err != nil {
+		return "", fetched{}, err
+	}
+	return targetDir, f, nil
+}
+
+func toolDirRoot(t ToolSpec) (string, error) {
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, ".local", t.DirRoot), nil
+}
+
+// removeStaleExtractDirs deletes .tmp-* extraction dirs left behind by a run
+// that was killed before it could clean up after itself.
+func removeStaleExtractDirs(root string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), ".tmp-") {
+			_ = os.RemoveAll(filepath.Join(root, e.Name()))
+		}
+	}
+}
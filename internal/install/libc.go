@@ -0,0 +1,56 @@
+package install
+
+import (
+	"strings"
+	"sync"
+
+	"nvimwiz/internal/sysinfo"
+)
+
+// hostLibc is the C library assets are matched against, detected once per
+// run.
+var hostLibc = sync.OnceValue(sysinfo.DetectLibc)
+
+// UnsupportedError is returned when a release has no build that runs on
+// this system, e.g. only glibc builds on Alpine.
+type UnsupportedError struct {
+	Tool   string
+	Reason string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Tool + " has no release build for this system (" + e.Reason + "); install it with your package manager instead"
+}
+
+// libcFits reports whether a build for req ("", "musl", "glibc" or
+// "glibc 2.31") runs on host, and if not, why. musl builds are taken to be
+// static; an unknown host libc fits everything.
+func libcFits(req string, host sysinfo.Libc) (bool, string) {
+	want := sysinfo.ParseLibc(req)
+	if want.Flavor != "glibc" || host.Flavor == "" {
+		return true, ""
+	}
+	need := "glibc"
+	if want.Version != "" {
+		need += " " + want.Version + " or newer"
+	}
+	if host.Flavor != "glibc" {
+		return false, "needs " + need + ", this system uses " + host.String()
+	}
+	if !host.AtLeast(want.Version) {
+		return false, "needs " + need + ", this system has " + host.String()
+	}
+	return true, ""
+}
+
+// libcNote describes why a build for req was picked on host, or "" when
+// the C library played no part.
+func libcNote(req string, host sysinfo.Libc, skipped []string) string {
+	if len(skipped) > 0 {
+		return "picked over " + strings.Join(skipped, "; ")
+	}
+	if req == "" || host.Flavor == "" {
+		return ""
+	}
+	return req + " build, this system has " + host.String()
+}
@@ -0,0 +1,117 @@
+package install
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"nvimwiz/internal/sysinfo"
+)
+
+var (
+	glibc235 = sysinfo.Libc{Flavor: "glibc", Version: "2.35"}
+	glibc228 = sysinfo.Libc{Flavor: "glibc", Version: "2.28"}
+	musl124  = sysinfo.Libc{Flavor: "musl", Version: "1.2.4"}
+)
+
+func TestLibcFits(t *testing.T) {
+	tests := []struct {
+		req     string
+		host    sysinfo.Libc
+		fits    bool
+		wantWhy string
+	}{
+		{"", musl124, true, ""},
+		{"musl", glibc235, true, ""},
+		{"musl", musl124, true, ""},
+		{"glibc", glibc228, true, ""},
+		{"glibc 2.31", glibc235, true, ""},
+		{"glibc 2.31", sysinfo.Libc{}, true, ""},
+		{"glibc 2.31", sysinfo.Libc{Flavor: "glibc"}, true, ""},
+		{"glibc 2.31", glibc228, false, "needs glibc 2.31 or newer, this system has glibc 2.28"},
+		{"glibc 2.31", musl124, false, "needs glibc 2.31 or newer, this system uses musl 1.2.4"},
+		{"glibc", musl124, false, "needs glibc, this system uses musl 1.2.4"},
+	}
+	for _, tt := range tests {
+		fits, why := libcFits(tt.req, tt.host)
+		if fits != tt.fits || why != tt.wantWhy {
+			t.Errorf("libcFits(%q, %s) = %v, %q; want %v, %q", tt.req, tt.host, fits, why, tt.fits, tt.wantWhy)
+		}
+	}
+}
+
+func TestLibcNote(t *testing.T) {
+	tests := []struct {
+		req     string
+		host    sysinfo.Libc
+		skipped []string
+		want    string
+	}{
+		{"", glibc235, nil, ""},
+		{"musl", sysinfo.Libc{}, nil, ""},
+		{"musl", musl124, nil, "musl build, this system has musl 1.2.4"},
+		{"glibc 2.31", glibc235, nil, "glibc 2.31 build, this system has glibc 2.35"},
+		{"musl", glibc228, []string{"a-gnu.tar.gz (needs glibc 2.31 or newer, this system has glibc 2.28)"},
+			"picked over a-gnu.tar.gz (needs glibc 2.31 or newer, this system has glibc 2.28)"},
+	}
+	for _, tt := range tests {
+		if got := libcNote(tt.req, tt.host, tt.skipped); got != tt.want {
+			t.Errorf("libcNote(%q, %s, %q) = %q, want %q", tt.req, tt.host, tt.skipped, got, tt.want)
+		}
+	}
+}
+
+func TestPickAssetByLibc(t *testing.T) {
+	spec := ToolSpec{
+		Repo:    "fd",
+		Title:   "fd",
+		Archive: ".tar.gz",
+		Assets: map[string][]string{
+			"linux/amd64": {"x86_64-unknown-linux-gnu", "x86_64-unknown-linux-musl"},
+		},
+		Libc: map[string]string{
+			"x86_64-unknown-linux-gnu":  "glibc 2.31",
+			"x86_64-unknown-linux-musl": "musl",
+		},
+	}
+	both := ghRelease{Assets: []ghAsset{
+		{Name: "fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz"},
+		{Name: "fd-v10.1.0-x86_64-unknown-linux-musl.tar.gz"},
+	}}
+	gnuOnly := ghRelease{Assets: both.Assets[:1]}
+
+	tests := []struct {
+		name        string
+		rel         ghRelease
+		host        sysinfo.Libc
+		want        string
+		unsupported bool
+	}{
+		{"new glibc", both, glibc235, "fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz", false},
+		{"old glibc", both, glibc228, "fd-v10.1.0-x86_64-unknown-linux-musl.tar.gz", false},
+		{"musl", both, musl124, "fd-v10.1.0-x86_64-unknown-linux-musl.tar.gz", false},
+		{"unknown libc", gnuOnly, sysinfo.Libc{}, "fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz", false},
+		{"musl, gnu only", gnuOnly, musl124, "", true},
+		{"old glibc, gnu only", gnuOnly, glibc228, "", true},
+	}
+	for _, tt := range tests {
+		a, note, err := spec.pickAssetFor(tt.rel, "linux/amd64", tt.host)
+		var ue *UnsupportedError
+		if tt.unsupported {
+			if !errors.As(err, &ue) || ue.Tool != "fd" || !strings.Contains(ue.Reason, "needs glibc 2.31") {
+				t.Errorf("%s: err = %v, want *UnsupportedError", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || a.Name != tt.want {
+			t.Errorf("%s: picked %q, %v; want %q", tt.name, a.Name, err, tt.want)
+		}
+		if tt.name == "old glibc" && !strings.HasPrefix(note, "picked over fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz") {
+			t.Errorf("%s: note = %q", tt.name, note)
+		}
+	}
+
+	if _, _, err := spec.pickAssetFor(ghRelease{}, "linux/amd64", glibc235); err == nil || errors.As(err, new(*UnsupportedError)) {
+		t.Errorf("empty release: err = %v, want asset not found", err)
+	}
+}
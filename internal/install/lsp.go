@@ -0,0 +1,396 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// ErrMissingToolchain is returned when the go or npm command a server needs
+// is not available.
+var ErrMissingToolchain = errors.New("toolchain not found")
+
+// ServerMethod is how a language server is installed.
+type ServerMethod int
+
+const (
+	// GitHubRelease unpacks a release archive and adds a wrapper script.
+	GitHubRelease ServerMethod = iota
+	// GoInstall runs go install with GOBIN set to the managed bin dir.
+	GoInstall
+	// Npm installs packages with npm into a managed prefix.
+	Npm
+)
+
+func (m ServerMethod) String() string {
+	switch m {
+	case GoInstall:
+		return "go install"
+	case Npm:
+		return "npm"
+	default:
+		return "GitHub release"
+	}
+}
+
+// ServerSpec declares a language server nvimwiz can install for an lsp.*
+// feature.
+type ServerSpec struct {
+	// Name is the lspconfig server name.
+	Name      string
+	FeatureID string
+	Method    ServerMethod
+	// Binaries are the commands the server provides; the first one is
+	// checked for status.
+	Binaries []string
+
+	// GoPackage is the module path for GoInstall, without a version.
+	GoPackage string
+	// NpmPackages are installed together for Npm.
+	NpmPackages []string
+	// Release locates the archive for GitHubRelease. BinDir is the
+	// binary's directory inside the unpacked release.
+	Release *ToolSpec
+}
+
+var servers = []ServerSpec{
+	{
+		Name:      "gopls",
+		FeatureID: "lsp.go",
+		Method:    GoInstall,
+		Binaries:  []string{"gopls"},
+		GoPackage: "golang.org/x/tools/gopls",
+	},
+	{
+		Name:        "pyright",
+		FeatureID:   "lsp.python",
+		Method:      Npm,
+		Binaries:    []string{"pyright-langserver", "pyright"},
+		NpmPackages: []string{"pyright"},
+	},
+	{
+		Name:        "ts_ls",
+		FeatureID:   "lsp.typescript",
+		Method:      Npm,
+		Binaries:    []string{"typescript-language-server", "tsserver"},
+		NpmPackages: []string{"typescript-language-server", "typescript"},
+	},
+	{
+		Name:        "html/cssls/jsonls",
+		FeatureID:   "lsp.web",
+		Method:      Npm,
+		Binaries:    []string{"vscode-html-language-server", "vscode-css-language-server", "vscode-json-language-server"},
+		NpmPackages: []string{"vscode-langservers-extracted"},
+	},
+	{
+		Name:        "bashls",
+		FeatureID:   "lsp.bash",
+		Method:      Npm,
+		Binaries:    []string{"bash-language-server"},
+		NpmPackages: []string{"bash-language-server"},
+	},
+	{
+		Name:      "lua_ls",
+		FeatureID: "lsp.lua",
+		Method:    GitHubRelease,
+		Binaries:  []string{"lua-language-server"},
+		Release: &ToolSpec{
+			Owner:   "LuaLS",
+			Repo:    "lua-language-server",
+			Binary:  "lua-language-server",
+			Archive: ".tar.gz",
+			Assets: map[string][]string{
+				"linux/amd64":  {"-linux-x64.tar.gz", "-linux-x64-musl.tar.gz"},
+				"linux/arm64":  {"-linux-arm64.tar.gz"},
+				"darwin/amd64": {"-darwin-x64.tar.gz"},
+				"darwin/arm64": {"-darwin-arm64.tar.gz"},
+			},
+			Libc: map[string]string{
+				"-linux-x64.tar.gz":      "glibc",
+				"-linux-x64-musl.tar.gz": "musl",
+				"-linux-arm64.tar.gz":    "glibc",
+			},
+			BinDir: "bin",
+		},
+	},
+}
+
+// Servers returns every language server nvimwiz can install.
+func Servers() []ServerSpec {
+	out := make([]ServerSpec, len(servers))
+	copy(out, servers)
+	return out
+}
+
+// ServersForFeature returns the servers an lsp.* feature needs.
+func ServersForFeature(featureID string) []ServerSpec {
+	out := []ServerSpec{}
+	for _, s := range servers {
+		if s.FeatureID == featureID {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// LSPDir is the managed language server directory.
+func LSPDir() (string, error) {
+	d, err := env.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "lsp"), nil
+}
+
+// LSPBinDir holds every managed server command; the generated config puts it
+// on PATH.
+func LSPBinDir() (string, error) {
+	d, err := LSPDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "bin"), nil
+}
+
+// ServerStatus says whether a server's command can be found.
+type ServerStatus struct {
+	Server    ServerSpec
+	Installed bool
+	// Managed is true when the command is in LSPBinDir rather than
+	// somewhere on PATH.
+	Managed bool
+	Path    string
+	// Missing lists files the server's receipt names that are gone; the
+	// managed install is then treated as absent so Apply redoes it.
+	Missing []string
+}
+
+// StatusForServer looks for s's command in the managed dir, then on PATH.
+func StatusForServer(s ServerSpec) ServerStatus {
+	st := ServerStatus{Server: s}
+	if len(s.Binaries) == 0 {
+		return st
+	}
+	if rc, ok, _ := receipt.Load(receipt.Server, s.Name); ok {
+		st.Missing = rc.Missing()
+	}
+	if bin, err := LSPBinDir(); err == nil && len(st.Missing) == 0 {
+		p := filepath.Join(bin, s.commandFile(s.Binaries[0]))
+		if _, err := os.Stat(p); err == nil {
+			st.Installed, st.Managed, st.Path = true, true, p
+			return st
+		}
+	}
+	if p, err := exec.LookPath(s.Binaries[0]); err == nil {
+		st.Installed, st.Path = true, p
+	}
+	return st
+}
+
+// InstallServer installs s into the managed directory and returns the path
+// of its main command.
+func InstallServer(ctx context.Context, s ServerSpec, opts Options, emit events.Sink) (string, error) {
+	root, err := LSPDir()
+	if err != nil {
+		return "", err
+	}
+	bin := filepath.Join(root, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		return "", err
+	}
+	if s.Method != GitHubRelease && opts.bundleDir() != "" {
+		return "", fmt.Errorf("%w: %s is installed with %s", ErrOffline, s.Name, s.Method)
+	}
+
+	// Each method fills in where the server came from and any files it
+	// placed outside bin.
+	var rc receipt.Receipt
+	switch s.Method {
+	case GoInstall:
+		rc, err = goInstallServer(ctx, s, bin, emit)
+	case Npm:
+		rc, err = npmInstallServer(ctx, s, root, bin, emit)
+	default:
+		rc, err = releaseInstallServer(ctx, s, opts, root, bin, emit)
+	}
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(bin, s.commandFile(s.Binaries[0]))
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s installed, but %s was not found in %s", s.Name, s.Binaries[0], bin)
+	}
+	rc.Kind, rc.Name = receipt.Server, s.Name
+	for _, b := range s.Binaries {
+		if p := filepath.Join(bin, s.commandFile(b)); !rc.Owns(p) {
+			if _, err := os.Lstat(p); err == nil {
+				rc.Paths = append(rc.Paths, p)
+			}
+		}
+	}
+	record(rc, emit)
+	emit.Log("Installed " + s.Name + " to " + path)
+	return path, nil
+}
+
+func goInstallServer(ctx context.Context, s ServerSpec, bin string, emit events.Sink) (receipt.Receipt, error) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return receipt.Receipt{}, fmt.Errorf("%w: %s needs go on PATH", ErrMissingToolchain, s.Name)
+	}
+	cmd := exec.CommandContext(ctx, goBin, "install", s.GoPackage+"@latest")
+	cmd.Env = append(os.Environ(), "GOBIN="+bin)
+	emit.Log("Running go install " + s.GoPackage + "@latest")
+	if err := runLogged(cmd, emit); err != nil {
+		return receipt.Receipt{}, err
+	}
+	return receipt.Receipt{Source: "go install " + s.GoPackage + "@latest"}, nil
+}
+
+func npmInstallServer(ctx context.Context, s ServerSpec, root, bin string, emit events.Sink) (receipt.Receipt, error) {
+	npm, nodeBin := "", NodeBinDir()
+	if nodeBin != "" {
+		npm = filepath.Join(nodeBin, "npm")
+	} else if p, err := exec.LookPath("npm"); err == nil {
+		npm = p
+	} else {
+		return receipt.Receipt{}, fmt.Errorf("%w: %s needs npm on PATH (enable install.node)", ErrMissingToolchain, s.Name)
+	}
+	prefix := filepath.Join(root, "npm")
+	args := append([]string{"install", "--global", "--prefix", prefix, "--no-audit", "--no-fund"}, s.NpmPackages...)
+	cmd := exec.CommandContext(ctx, npm, args...)
+	if nodeBin != "" {
+		// npm and the packages' install scripts run "node" from PATH.
+		cmd.Env = append(os.Environ(), "PATH="+nodeBin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	emit.Log("Running npm install " + strings.Join(s.NpmPackages, " "))
+	if err := runLogged(cmd, emit); err != nil {
+		return receipt.Receipt{}, err
+	}
+
+	npmBin := filepath.Join(prefix, "bin")
+	modules := filepath.Join(prefix, "lib", "node_modules")
+	if runtime.GOOS == "windows" {
+		npmBin = prefix
+		modules = filepath.Join(prefix, "node_modules")
+	}
+	rc := receipt.Receipt{Source: "npm install " + strings.Join(s.NpmPackages, " ")}
+	for _, pkg := range s.NpmPackages {
+		rc.Paths = append(rc.Paths, filepath.Join(modules, npmPackageName(pkg)))
+	}
+	for _, b := range s.Binaries {
+		src := filepath.Join(npmBin, s.commandFile(b))
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := replaceSymlink(filepath.Join(bin, s.commandFile(b)), src); err != nil {
+			return receipt.Receipt{}, err
+		}
+		rc.Paths = append(rc.Paths, src)
+	}
+	return rc, nil
+}
+
+// npmPackageName strips a version from an npm install spec such as
+// "typescript@5" or "@vue/language-server@2".
+func npmPackageName(spec string) string {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+func releaseInstallServer(ctx context.Context, s ServerSpec, opts Options, root, bin string, emit events.Sink) (receipt.Receipt, error) {
+	t := *s.Release
+	rel, err := fetchRelease(ctx, opts.remote(), t.Owner, t.Repo, "")
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	pkgRoot := filepath.Join(root, "pkgs", t.Repo)
+	targetDir := filepath.Join(pkgRoot, releaseDirName(rel))
+	real := filepath.Join(targetDir, t.BinDir, t.ExeName())
+	wrapper := filepath.Join(bin, t.ExeName())
+	rc := receipt.Receipt{Kind: receipt.Server, Name: s.Name, Version: releaseVersion(rel), Paths: []string{targetDir, wrapper}}
+
+	if _, err := os.Stat(real); err == nil {
+		emit.Log(s.Name + " " + rel.TagName + " already installed, skipping download")
+		return keepSource(rc), writeWrapper(wrapper, real)
+	}
+
+	if err := os.MkdirAll(pkgRoot, 0o755); err != nil {
+		return receipt.Receipt{}, err
+	}
+	removeStaleExtractDirs(pkgRoot)
+	tmpDir, err := os.MkdirTemp("", "nvimwiz-"+t.Binary+"-*")
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Some servers ship archives without a top-level directory, so the
+	// whole extraction dir becomes the release dir.
+	extractTmp := filepath.Join(pkgRoot, ".tmp-"+time.Now().Format("20060102-150405"))
+	defer os.RemoveAll(extractTmp)
+	f, err := fetchAndExtract(ctx, t, rel, opts, tmpDir, extractTmp, emit)
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	rc.Source, rc.SHA256 = f.URL, f.SHA256
+	src := extractTmp
+	if found, err := findFile(extractTmp, t.ExeName()); err == nil {
+		src = filepath.Dir(filepath.Dir(found))
+		if t.BinDir == "" {
+			src = filepath.Dir(found)
+		}
+	}
+	_ = os.RemoveAll(targetDir)
+	if err := os.Rename(src, targetDir); err != nil {
+		return receipt.Receipt{}, err
+	}
+	return rc, writeWrapper(wrapper, real)
+}
+
+// writeWrapper writes a script that execs target, so servers that locate
+// their support files relative to their own path keep working.
+func writeWrapper(path, target string) error {
+	if runtime.GOOS == "windows" {
+		return replaceSymlink(path, target)
+	}
+	script := "#!/usr/bin/env sh\nexec " + shellQuote(target) + " \"$@\"\n"
+	return os.WriteFile(path, []byte(script), 0o755)
+}
+
+// shellQuote quotes s for sh, where nothing inside single quotes is special.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func runLogged(cmd *exec.Cmd, emit events.Sink) error {
+	out, err := cmd.CombinedOutput()
+	if s := strings.TrimSpace(string(out)); s != "" {
+		emit.Log(s)
+	}
+	return err
+}
+
+// commandFile is the file name of one of s's commands on this OS; npm
+// creates .cmd shims on Windows.
+func (s ServerSpec) commandFile(name string) string {
+	if runtime.GOOS != "windows" {
+		return name
+	}
+	if s.Method == Npm {
+		return name + ".cmd"
+	}
+	return name + ".exe"
+}
@@ -0,0 +1,37 @@
+package install
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteWrapperQuotesTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("wrappers are symlinks on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), `it's "$(touch pwned)" $HOME`)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "server")
+	if err := os.WriteFile(target, []byte("#!/bin/sh\nprintf '%s|' \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wrapper := filepath.Join(t.TempDir(), "server")
+	if err := writeWrapper(wrapper, target); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(wrapper, "--stdio", "a b")
+	cmd.Dir = t.TempDir()
+	out, err := cmd.CombinedOutput()
+	if err != nil || string(out) != "--stdio|a b|" {
+		t.Fatalf("wrapper ran %q, %v", out, err)
+	}
+	if _, err := os.Stat(filepath.Join(cmd.Dir, "pwned")); !os.IsNotExist(err) {
+		t.Fatal("the target path was expanded by the shell")
+	}
+}
@@ -3,132 +3,55 @@ package install
 import (
 	"context"
 	"errors"
-	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"time"
 
-	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
 )
 
-func InstallNeovim(ctx context.Context, verify string, log func(string)) (string, error) {
-	rel, err := fetchLatestRelease(ctx, "neovim", "neovim")
-	if err != nil {
-		return "", err
-	}
-
-	latest := normalizeVersion(rel.TagName)
-	if cur, path, ok := installedCommandVersion(ctx, "nvim", "--version"); ok && cur == latest {
-		if log != nil {
-			log("Neovim already up to date (" + rel.TagName + "), skipping download")
-		}
-		return path, nil
-	}
-	asset, ok := findAsset(rel, func(a ghAsset) bool {
-		name := a.Name
-		if !strings.HasSuffix(name, ".tar.gz") {
-			return false
+// EnsureNeovimVersion makes sure the release matching opts.Version is
+// unpacked under ~/.local/nvim without touching the ~/.local/bin/nvim link.
+// An exact tag that is already installed needs no network access.
+func EnsureNeovimVersion(ctx context.Context, opts Options, emit events.Sink) (NvimVersion, error) {
+	spec := strings.TrimSpace(opts.Version)
+	if spec == "" || strings.EqualFold(spec, "latest") {
+		return NvimVersion{}, errors.New("no neovim version selected")
+	}
+	if !isReleaseChannel(spec) {
+		if v, err := findNvimVersion(spec); err == nil {
+			emit.Log("Neovim " + v.Tag + " already installed at " + v.Dir)
+			return v, nil
 		}
-		return matchNeovimAsset(name)
-	})
-	if !ok {
-		return "", errors.New("neovim asset not found")
-	}
-
-	workRoot, err := localNvimRoot()
-	if err != nil {
-		return "", err
-	}
-	if err := os.MkdirAll(workRoot, 0o755); err != nil {
-		return "", err
 	}
 
-	tmpDir, err := os.MkdirTemp("", "nvimwiz-nvim-*")
+	t, _ := LookupTool("neovim")
+	rel, err := fetchRelease(ctx, opts.remote(), t.Owner, t.Repo, spec)
 	if err != nil {
-		return "", err
-	}
-	defer os.RemoveAll(tmpDir)
-
-	tarPath := filepath.Join(tmpDir, asset.Name)
-	if log != nil {
-		log("Downloading " + asset.Name)
-	}
-	if err := downloadFile(ctx, asset.BrowserDownloadURL, tarPath); err != nil {
-		return "", err
+		return NvimVersion{}, err
 	}
-
-	if err := verifyAssetIfPossible(ctx, verify, rel, asset, tarPath, log); err != nil {
-		return "", err
-	}
-
-	extractTmp := filepath.Join(workRoot, ".tmp-"+time.Now().Format("20060102-150405"))
-	if err := os.MkdirAll(extractTmp, 0o755); err != nil {
-		return "", err
+	if v, err := findNvimVersion(releaseDirName(rel)); err == nil {
+		emit.Log("Neovim " + v.Tag + " already installed at " + v.Dir)
+		return v, nil
 	}
-	top, err := extractTarGz(tarPath, extractTmp)
+	targetDir, f, err := installDirRelease(ctx, t, rel, opts, emit)
 	if err != nil {
-		_ = os.RemoveAll(extractTmp)
-		return "", err
-	}
-	srcDir := filepath.Join(extractTmp, top)
-	if _, err := os.Stat(srcDir); err != nil {
-		_ = os.RemoveAll(extractTmp)
-		return "", errors.New("extraction failed")
+		return NvimVersion{}, err
 	}
-
-	targetDir := filepath.Join(workRoot, rel.TagName)
-	_ = os.RemoveAll(targetDir)
-	if err := os.Rename(srcDir, targetDir); err != nil {
-		_ = os.RemoveAll(extractTmp)
-		return "", err
-	}
-	_ = os.RemoveAll(extractTmp)
-
-	lb, err := env.LocalBin()
-	if err != nil {
-		return "", err
-	}
-	if err := os.MkdirAll(lb, 0o755); err != nil {
-		return "", err
-	}
-	bin := filepath.Join(targetDir, "bin", "nvim")
-	link := filepath.Join(lb, "nvim")
-	if runtime.GOOS == "windows" {
-		bin = filepath.Join(targetDir, "bin", "nvim.exe")
-		link = filepath.Join(lb, "nvim.exe")
-	}
-	if err := replaceSymlink(link, bin); err != nil {
-		return "", err
-	}
-	if log != nil {
-		log("Installed nvim to " + bin)
-	}
-	return link, nil
+	v := NvimVersion{Tag: filepath.Base(targetDir), Dir: targetDir, Bin: nvimBinIn(targetDir)}
+	record(receipt.Receipt{Kind: receipt.Neovim, Name: v.Tag, Version: releaseVersion(rel), Source: f.URL, SHA256: f.SHA256, Paths: []string{targetDir}}, emit)
+	emit.Log("Installed Neovim " + v.Tag + " to " + v.Dir)
+	return v, nil
 }
 
 func localNvimRoot() (string, error) {
-	h, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(h, ".local", "nvim"), nil
+	t, _ := LookupTool("neovim")
+	return toolDirRoot(t)
 }
 
-func matchNeovimAsset(name string) bool {
-	goos := runtime.GOOS
-	arch := runtime.GOARCH
-	if goos == "linux" && arch == "amd64" {
-		return name == "nvim-linux-x86_64.tar.gz"
-	}
-	if goos == "linux" && arch == "arm64" {
-		return name == "nvim-linux-arm64.tar.gz"
-	}
-	if goos == "darwin" && arch == "amd64" {
-		return name == "nvim-macos-x86_64.tar.gz"
-	}
-	if goos == "darwin" && arch == "arm64" {
-		return name == "nvim-macos-arm64.tar.gz"
-	}
-	return false
+// isReleaseChannel reports whether spec names a moving release ("stable",
+// "nightly") rather than a fixed tag.
+func isReleaseChannel(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	return strings.EqualFold(spec, "stable") || strings.EqualFold(spec, "nightly")
 }
@@ -0,0 +1,361 @@
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// NodeFeatureID is the catalog feature that installs Node.js.
+const NodeFeatureID = "install.node"
+
+// MinNodeMajor is the oldest Node.js major the npm-based language servers
+// run on. A system node older than this does not count.
+const MinNodeMajor = 18
+
+var nodeDistURL = "https://nodejs.org/dist"
+
+// nodeCommands are linked from the release's bin dir into ~/.local/bin.
+var nodeCommands = []string{"node", "npm", "npx"}
+
+var nodeVersionPattern = regexp.MustCompile(`v?([0-9]+\.[0-9]+\.[0-9]+)`)
+
+type nodeRelease struct {
+	Version string `json:"version"`
+	// LTS is false for current releases and the codename ("Jod") for LTS
+	// ones.
+	LTS any `json:"lts"`
+}
+
+func (r nodeRelease) isLTS() bool {
+	switch v := r.LTS.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	return false
+}
+
+// NodeInstallDir is where Node.js releases are unpacked, one directory per
+// version.
+func NodeInstallDir() (string, error) {
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, ".local", "node"), nil
+}
+
+// SystemNodeOK reports whether a node at least MinNodeMajor is on PATH and
+// npm is next to it.
+func SystemNodeOK(ctx context.Context) bool {
+	v, _, ok := installedCommandVersion(ctx, nodeVersionPattern, "node", "--version")
+	if !ok || nodeMajor(v) < MinNodeMajor {
+		return false
+	}
+	_, err := exec.LookPath("npm")
+	return err == nil
+}
+
+// NeedsNode reports whether the enabled features install language servers
+// with npm.
+func NeedsNode(features map[string]bool) bool {
+	if !features["lsp.servers"] {
+		return false
+	}
+	for _, s := range servers {
+		if s.Method == Npm && features[s.FeatureID] {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeBinDir is the bin dir of the Node.js release ~/.local/bin/node links
+// to, or "" when nvimwiz has not installed one.
+func NodeBinDir() string {
+	lb, err := env.LocalBin()
+	if err != nil {
+		return ""
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(lb, nodeExe()))
+	if err != nil {
+		return ""
+	}
+	root, err := NodeInstallDir()
+	if err != nil || !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.Dir(target)
+}
+
+// InstallNode installs the Node.js release matching opts.Version ("lts" or
+// empty for the newest LTS, "latest", a major like "20", or "v20.11.1")
+// under ~/.local/node/<version> and links node, npm and npx into
+// ~/.local/bin. It returns the path of the node link.
+func InstallNode(ctx context.Context, opts Options, emit events.Sink) (string, error) {
+	rel, err := resolveNodeRelease(ctx, opts.remote(), opts.Version)
+	if err != nil {
+		return "", err
+	}
+	root, err := NodeInstallDir()
+	if err != nil {
+		return "", err
+	}
+	targetDir := filepath.Join(root, rel.Version)
+
+	rc := receipt.Receipt{Kind: receipt.Tool, Name: "node", Version: normalizeVersion(rel.Version), Paths: []string{targetDir}}
+	if _, err := os.Stat(filepath.Join(targetDir, "bin", nodeExe())); err == nil {
+		emit.Log("Node.js " + rel.Version + " already installed at " + targetDir)
+		rc = keepSource(rc)
+	} else {
+		url, sum, err := downloadNode(ctx, rel, opts, root, targetDir, emit)
+		if err != nil {
+			return "", err
+		}
+		rc.Source, rc.SHA256 = url, sum
+		emit.Log("Installed Node.js " + rel.Version + " to " + targetDir)
+	}
+
+	lb, err := env.LocalBin()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(lb, 0o755); err != nil {
+		return "", err
+	}
+	for _, c := range nodeCommands {
+		src := filepath.Join(targetDir, "bin", c)
+		if _, err := os.Stat(src); err != nil {
+			return "", fmt.Errorf("node %s: %s missing from the release", rel.Version, c)
+		}
+		if err := replaceSymlink(filepath.Join(lb, c), src); err != nil {
+			return "", err
+		}
+		rc.Paths = append(rc.Paths, filepath.Join(lb, c))
+	}
+	record(rc, emit)
+	return filepath.Join(lb, nodeExe()), nil
+}
+
+// downloadNode unpacks rel into targetDir and returns the URL and sha256 of
+// the tarball it came from.
+func downloadNode(ctx context.Context, rel nodeRelease, opts Options, root, targetDir string, emit events.Sink) (string, string, error) {
+	name, err := nodeAssetName(rel)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", "", err
+	}
+	removeStaleExtractDirs(root)
+
+	tmpDir, err := os.MkdirTemp("", "nvimwiz-node-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, name)
+	emit.Log("Downloading " + name)
+	r := opts.remote()
+	url := nodeDistURL + "/" + rel.Version + "/" + name
+	if err := downloadFile(ctx, r, url, archivePath, emit); err != nil {
+		return "", "", err
+	}
+	if err := verifyNode(ctx, r, opts.Verify, rel, name, archivePath, emit); err != nil {
+		return "", "", err
+	}
+	sum, err := receipt.FileSHA256(archivePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	extractTmp := filepath.Join(root, ".tmp-"+time.Now().Format("20060102-150405"))
+	defer os.RemoveAll(extractTmp)
+	top, err := extractTarGz(ctx, archivePath, extractTmp)
+	if err != nil {
+		return "", "", err
+	}
+	commitDownload(r, url, archivePath, emit)
+	_ = os.RemoveAll(targetDir)
+	if err := os.Rename(filepath.Join(extractTmp, top), targetDir); err != nil {
+		return "", "", err
+	}
+	return url, sum, nil
+}
+
+// verifyNode checks the tarball against the release's SHASUMS256.txt. Every
+// Node.js release publishes one, so a missing entry is an error unless
+// verification is off.
+func verifyNode(ctx context.Context, r remote, policy string, rel nodeRelease, name, path string, emit events.Sink) error {
+	p := strings.ToLower(strings.TrimSpace(policy))
+	switch p {
+	case "off":
+		return nil
+	case "signed":
+		return errors.New("node.js releases are signed with GPG, which verify \"signed\" cannot check; use \"require\" to verify them against SHASUMS256.txt")
+	}
+	sumsPath := filepath.Join(filepath.Dir(path), "SHASUMS256.txt")
+	emit.Log("Downloading checksum SHASUMS256.txt")
+	sumsURL := nodeDistURL + "/" + rel.Version + "/SHASUMS256.txt"
+	if err := downloadFile(ctx, r, sumsURL, sumsPath, emit); err != nil {
+		if p == "require" {
+			return err
+		}
+		checksumSkipped(emit, name, "Checksum download failed, skipping verification")
+		return nil
+	}
+	m, err := parseChecksumFile(sumsPath)
+	if err != nil {
+		return err
+	}
+	commitDownload(r, sumsURL, sumsPath, emit)
+	expected := m[name]
+	if expected == "" {
+		return errors.New("SHASUMS256.txt has no entry for " + name)
+	}
+	got, err := receipt.FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, expected) {
+		return errors.New("checksum verification failed for " + name)
+	}
+	emit.Emit(events.Event{Kind: events.ChecksumVerified, File: name, Source: "SHASUMS256.txt", Message: "Checksum verified for " + name + " (from SHASUMS256.txt)"})
+	return nil
+}
+
+func resolveNodeRelease(ctx context.Context, r remote, spec string) (nodeRelease, error) {
+	rels, err := fetchNodeIndex(ctx, r)
+	if err != nil {
+		return nodeRelease{}, err
+	}
+	return pickNodeRelease(rels, spec)
+}
+
+func pickNodeRelease(rels []nodeRelease, spec string) (nodeRelease, error) {
+	spec = strings.TrimSpace(spec)
+	want := "v" + normalizeVersion(spec)
+	// index.json lists releases newest first.
+	for _, r := range rels {
+		switch {
+		case spec == "" || strings.EqualFold(spec, "lts"):
+			if r.isLTS() {
+				return r, nil
+			}
+		case strings.EqualFold(spec, "latest"):
+			return r, nil
+		case strings.Count(want, ".") == 0:
+			if strings.HasPrefix(r.Version, want+".") {
+				return r, nil
+			}
+		default:
+			if r.Version == want {
+				return r, nil
+			}
+		}
+	}
+	if spec == "" {
+		spec = "lts"
+	}
+	return nodeRelease{}, fmt.Errorf("node.js release %s not found", spec)
+}
+
+func fetchNodeIndex(ctx context.Context, r remote) ([]nodeRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.rewrite(nodeDistURL+"/index.json"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nvimwiz")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("node.js index: %w", &httpError{StatusCode: resp.StatusCode})
+	}
+	var rels []nodeRelease
+	if err := json.NewDecoder(resp.Body).Decode(&rels); err != nil {
+		return nil, err
+	}
+	return rels, nil
+}
+
+// nodeMinGlibc is the oldest glibc the official Linux builds of Node.js 18
+// and later run on.
+const nodeMinGlibc = "2.28"
+
+// nodeAssetName is the tarball for this OS and architecture. The official
+// Linux builds need glibc, so musl and older glibc hosts are unsupported.
+func nodeAssetName(rel nodeRelease) (string, error) {
+	osName := map[string]string{"linux": "linux", "darwin": "darwin"}[runtime.GOOS]
+	arch := map[string]string{"amd64": "x64", "arm64": "arm64"}[runtime.GOARCH]
+	if osName == "" || arch == "" {
+		return "", fmt.Errorf("no node.js build for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	if osName == "linux" {
+		if ok, why := libcFits("glibc "+nodeMinGlibc, hostLibc()); !ok {
+			return "", &UnsupportedError{Tool: "Node.js", Reason: why}
+		}
+	}
+	return "node-" + rel.Version + "-" + osName + "-" + arch + ".tar.gz", nil
+}
+
+func nodeStatus(ctx context.Context, opts Options) ToolStatus {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	st := installedStatus("node", nodeExe(), func() (string, string, bool) {
+		return installedCommandVersion(ctx, nodeVersionPattern, "node", "--version")
+	})
+	if v := strings.TrimSpace(opts.Version); v != "" && !strings.EqualFold(v, "lts") {
+		st.Pinned = v
+	}
+	rel, err := resolveNodeRelease(ctx, opts.remote(), opts.Version)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.TargetTag = rel.Version
+	st.TargetVersion = normalizeVersion(rel.Version)
+	st.TargetOK = true
+	if name, err := nodeAssetName(rel); err == nil {
+		st.Asset = name
+	} else {
+		st.AssetNote = err.Error()
+	}
+	return st
+}
+
+func nodeMajor(v string) int {
+	major, _, _ := strings.Cut(normalizeVersion(v), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func nodeExe() string {
+	if runtime.GOOS == "windows" {
+		return "node.exe"
+	}
+	return "node"
+}
@@ -0,0 +1,318 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+)
+
+// DefaultNvimKeep is how many installed Neovim versions PruneNvimVersions
+// keeps when no count is given.
+const DefaultNvimKeep = 3
+
+// NvimVersion is one Neovim install under ~/.local/nvim.
+type NvimVersion struct {
+	Tag     string
+	Dir     string
+	Bin     string
+	Active  bool
+	ModTime time.Time
+}
+
+func nvimBinIn(dir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, "bin", "nvim.exe")
+	}
+	return filepath.Join(dir, "bin", "nvim")
+}
+
+func nvimLinkPath() (string, error) {
+	lb, err := env.LocalBin()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(lb, "nvim.exe"), nil
+	}
+	return filepath.Join(lb, "nvim"), nil
+}
+
+// ActiveNvimTag returns the installed version the ~/.local/bin/nvim symlink
+// points at, or "" when it is missing or points elsewhere.
+func ActiveNvimTag() (string, error) {
+	root, err := localNvimRoot()
+	if err != nil {
+		return "", err
+	}
+	link, err := nvimLinkPath()
+	if err != nil {
+		return "", err
+	}
+	target, err := os.Readlink(link)
+	if err != nil {
+		return "", nil
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	rel, err := filepath.Rel(root, filepath.Clean(target))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")[0], nil
+}
+
+// ListNvimVersions returns the installed Neovim versions, newest first.
+func ListNvimVersions() ([]NvimVersion, error) {
+	root, err := localNvimRoot()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []NvimVersion{}, nil
+		}
+		return nil, err
+	}
+	active, _ := ActiveNvimTag()
+
+	out := []NvimVersion{}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		bin := nvimBinIn(dir)
+		if _, err := os.Stat(bin); err != nil {
+			continue
+		}
+		v := NvimVersion{Tag: e.Name(), Dir: dir, Bin: bin, Active: e.Name() == active}
+		if fi, err := e.Info(); err == nil {
+			v.ModTime = fi.ModTime()
+		}
+		out = append(out, v)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if c := compareVersions(out[i].Tag, out[j].Tag); c != 0 {
+			return c > 0
+		}
+		return out[i].ModTime.After(out[j].ModTime)
+	})
+	return out, nil
+}
+
+func findNvimVersion(tag string) (NvimVersion, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return NvimVersion{}, errors.New("version is empty")
+	}
+	items, err := ListNvimVersions()
+	if err != nil {
+		return NvimVersion{}, err
+	}
+	for _, v := range items {
+		if v.Tag == tag {
+			return v, nil
+		}
+	}
+	for _, v := range items {
+		if normalizeVersion(v.Tag) == normalizeVersion(tag) {
+			return v, nil
+		}
+	}
+	return NvimVersion{}, fmt.Errorf("neovim %s is not installed", tag)
+}
+
+// InstalledNvimVersion looks up an installed version by tag, with or
+// without the "v" prefix.
+func InstalledNvimVersion(tag string) (NvimVersion, bool) {
+	v, err := findNvimVersion(tag)
+	return v, err == nil
+}
+
+// UseNvimVersion points ~/.local/bin/nvim at an installed version and
+// updates the neovim receipt to match.
+func UseNvimVersion(tag string) (NvimVersion, error) {
+	v, err := findNvimVersion(tag)
+	if err != nil {
+		return NvimVersion{}, err
+	}
+	link, err := nvimLinkPath()
+	if err != nil {
+		return NvimVersion{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		return NvimVersion{}, err
+	}
+	if err := replaceSymlink(link, v.Bin); err != nil {
+		return NvimVersion{}, err
+	}
+	if err := recordNvimUse(v, link); err != nil {
+		return NvimVersion{}, err
+	}
+	v.Active = true
+	return v, nil
+}
+
+// NvimPin is a Neovim version something besides ~/.local/bin/nvim depends
+// on, such as a profile's launcher.
+type NvimPin struct {
+	// Owner names the dependant in errors, e.g. `launcher "nvim-work"`.
+	Owner string
+	// Spec is the version asked for. A release channel such as "stable"
+	// pins nothing by itself, since the tag it resolves to changes.
+	Spec string
+	// Bin is the binary the launcher execs, when it exists.
+	Bin string
+}
+
+// holds reports whether p keeps v in use.
+func (p NvimPin) holds(v NvimVersion) bool {
+	if s := strings.TrimSpace(p.Spec); s != "" && !isReleaseChannel(s) && normalizeVersion(s) == normalizeVersion(v.Tag) {
+		return true
+	}
+	if p.Bin == "" {
+		return false
+	}
+	rel, err := filepath.Rel(v.Dir, filepath.Clean(p.Bin))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// RemoveNvimVersion deletes an installed version and its receipt. The
+// active one and one that a pin holds are refused.
+func RemoveNvimVersion(tag string, pins ...NvimPin) error {
+	v, err := findNvimVersion(tag)
+	if err != nil {
+		return err
+	}
+	if v.Active {
+		return fmt.Errorf("neovim %s is in use; switch to another version first", v.Tag)
+	}
+	for _, p := range pins {
+		if p.holds(v) {
+			return fmt.Errorf("neovim %s is used by %s; bind it to another version first", v.Tag, p.Owner)
+		}
+	}
+	if err := os.RemoveAll(v.Dir); err != nil {
+		return err
+	}
+	return forgetNvimVersion(v)
+}
+
+// PruneNvimVersions removes all but the newest keep versions. The active
+// version and those a pin holds are never removed and do not count against
+// keep.
+func PruneNvimVersions(keep int, pins ...NvimPin) ([]string, error) {
+	if keep < 0 {
+		keep = 0
+	}
+	items, err := ListNvimVersions()
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	kept := 0
+	for _, v := range items {
+		if v.Active || pinned(v, pins) {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(v.Dir); err != nil {
+			return removed, err
+		}
+		if err := forgetNvimVersion(v); err != nil {
+			return removed, err
+		}
+		removed = append(removed, v.Tag)
+	}
+	return removed, nil
+}
+
+func pinned(v NvimVersion, pins []NvimPin) bool {
+	for _, p := range pins {
+		if p.holds(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions orders tags like v0.10.2 and v0.11.0-dev-42 numerically;
+// a pre-release sorts before its release. Tags without a version number sort
+// after all numbered ones.
+func compareVersions(a, b string) int {
+	coreA, preA, okA := versionParts(a)
+	coreB, preB, okB := versionParts(b)
+	if !okA || !okB {
+		switch {
+		case okA:
+			return 1
+		case okB:
+			return -1
+		}
+		return strings.Compare(a, b)
+	}
+	if c := compareInts(coreA, coreB); c != 0 {
+		return c
+	}
+	switch {
+	case preA == nil && preB == nil:
+		return 0
+	case preA == nil:
+		return 1
+	case preB == nil:
+		return -1
+	}
+	return compareInts(preA, preB)
+}
+
+func compareInts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// versionParts splits "0.11.0-dev-42+gabc" into [0 11 0] and [42]. pre is
+// nil for a release.
+func versionParts(tag string) (core, pre []int, ok bool) {
+	v := normalizeVersion(tag)
+	v, _, _ = strings.Cut(v, "+")
+	if v == "" || v[0] < '0' || v[0] > '9' {
+		return nil, nil, false
+	}
+	coreStr, preStr, hasPre := strings.Cut(v, "-")
+	for _, f := range strings.Split(coreStr, ".") {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, nil, false
+		}
+		core = append(core, n)
+	}
+	if hasPre {
+		pre = []int{}
+		for _, f := range strings.FieldsFunc(preStr, func(r rune) bool { return r < '0' || r > '9' }) {
+			if n, err := strconv.Atoi(f); err == nil {
+				pre = append(pre, n)
+			}
+		}
+	}
+	return core, pre, true
+}
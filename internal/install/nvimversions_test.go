@@ -0,0 +1,72 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeNvimVersions installs empty Neovim versions under a temporary home,
+// with receipts kept there too, and returns the ~/.local/nvim root.
+func fakeNvimVersions(t *testing.T, tags ...string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".local", "state"))
+	root := filepath.Join(home, ".local", "nvim")
+	for _, tag := range tags {
+		bin := nvimBinIn(filepath.Join(root, tag))
+		if err := os.MkdirAll(filepath.Dir(bin), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestPruneNvimVersionsKeepsPinned(t *testing.T) {
+	root := fakeNvimVersions(t, "v0.9.5", "v0.10.0", "v0.10.1", "v0.10.2", "v0.11.0")
+	if _, err := UseNvimVersion("v0.11.0"); err != nil {
+		t.Fatal(err)
+	}
+	pins := []NvimPin{
+		{Owner: "exact", Spec: "0.9.5"},
+		// Bound to "stable"; the spec alone matches nothing, the launcher
+		// target keeps the tag it resolved to.
+		{Owner: "stable", Spec: "stable", Bin: nvimBinIn(filepath.Join(root, "v0.10.0"))},
+	}
+	removed, err := PruneNvimVersions(1, pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v0.10.1"}; !reflect.DeepEqual(removed, want) {
+		t.Fatalf("removed %v, want %v", removed, want)
+	}
+	for _, tag := range []string{"v0.9.5", "v0.10.0", "v0.10.2", "v0.11.0"} {
+		if _, ok := InstalledNvimVersion(tag); !ok {
+			t.Errorf("%s was removed", tag)
+		}
+	}
+}
+
+func TestRemoveNvimVersionRefusesPinned(t *testing.T) {
+	root := fakeNvimVersions(t, "v0.10.0", "v0.10.2", "v0.11.0")
+	if _, err := UseNvimVersion("v0.11.0"); err != nil {
+		t.Fatal(err)
+	}
+	pins := []NvimPin{
+		{Owner: "exact", Spec: "v0.10.2"},
+		{Owner: "nightly", Spec: "nightly", Bin: nvimBinIn(filepath.Join(root, "v0.10.0"))},
+	}
+	for _, tag := range []string{"v0.11.0", "v0.10.2", "v0.10.0"} {
+		if err := RemoveNvimVersion(tag, pins...); err == nil {
+			t.Errorf("removing %s succeeded, want refusal", tag)
+		}
+	}
+	if err := RemoveNvimVersion("v0.10.0"); err != nil {
+		t.Fatalf("removing an unpinned version: %v", err)
+	}
+}
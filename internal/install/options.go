@@ -0,0 +1,31 @@
+package install
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Options carries the per-profile settings an installer needs.
+type Options struct {
+	// Verify is the checksum policy: "auto", "require", "off", or
+	// "signed" to require a signature by a pinned key.
+	Verify string
+	// SigningKeys are public keys (minisign or PEM) accepted for "signed"
+	// on top of the ones the tool's spec pins.
+	SigningKeys []string
+	// Version pins the release to install: "latest" (or empty), a moving
+	// tag such as "stable" or "nightly", or a concrete tag like "v0.10.2".
+	Version string
+	// Source overrides where releases are looked up and downloaded.
+	Source Source
+	// Bundle is a directory made by "nvimwiz bundle create" to install
+	// from instead of the network; NVIMWIZ_BUNDLE sets it too.
+	Bundle string
+	// HTTPClient replaces the default client, e.g. to talk to a stand-in
+	// server.
+	HTTPClient *http.Client
+}
+
+func (o Options) signed() bool {
+	return strings.EqualFold(strings.TrimSpace(o.Verify), "signed")
+}
@@ -0,0 +1,54 @@
+package install
+
+import (
+	"context"
+	"path/filepath"
+
+	"nvimwiz/internal/env"
+)
+
+type ToolPreview struct {
+	Status ToolStatus
+	Repo   string
+	Skip   bool
+	Files  []string
+}
+
+// PreviewFeature reports what installing featureID would do without
+// downloading or writing anything.
+func PreviewFeature(ctx context.Context, featureID string, opts Options) (ToolPreview, bool) {
+	st, ok := StatusForFeature(ctx, featureID, opts)
+	if !ok {
+		return ToolPreview{}, false
+	}
+	pv := ToolPreview{Status: st}
+	pv.Skip = st.UpToDate()
+
+	lb, _ := env.LocalBin()
+	if featureID == NodeFeatureID {
+		pv.Repo = "nodejs.org/dist"
+		if root, err := NodeInstallDir(); err == nil && st.TargetOK {
+			pv.Files = append(pv.Files, filepath.Join(root, st.TargetTag)+string(filepath.Separator))
+		}
+		if lb != "" {
+			for _, c := range nodeCommands {
+				pv.Files = append(pv.Files, filepath.Join(lb, c)+" (symlink)")
+			}
+		}
+		return pv, true
+	}
+
+	t, _ := ToolByFeature(featureID)
+	pv.Repo = t.Owner + "/" + t.Repo
+	if t.keepsReleaseDir() {
+		if root, err := toolDirRoot(t); err == nil && st.TargetOK {
+			pv.Files = append(pv.Files, filepath.Join(root, "v"+st.TargetVersion)+string(filepath.Separator))
+		}
+		if lb != "" {
+			pv.Files = append(pv.Files, filepath.Join(lb, t.ExeName())+" (symlink)")
+		}
+	} else if lb != "" {
+		pv.Files = append(pv.Files, filepath.Join(lb, t.ExeName()))
+	}
+	return pv, true
+}
@@ -0,0 +1,101 @@
+package install
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitWait is the longest nvimwiz sleeps for the GitHub API rate
+// limit to reset; anything longer fails with a RateLimitError.
+const maxRateLimitWait = time.Minute
+
+// RateLimitError is returned when the GitHub API refuses requests until
+// Reset.
+type RateLimitError struct {
+	Limit         int
+	Reset         time.Time
+	Authenticated bool
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	if e.Limit > 0 {
+		msg += fmt.Sprintf(" (%d requests/hour)", e.Limit)
+	}
+	if !e.Reset.IsZero() {
+		msg += fmt.Sprintf("; it resets at %s (in %s)", e.Reset.Local().Format("15:04:05"), time.Until(e.Reset).Round(time.Second))
+	}
+	if !e.Authenticated {
+		msg += ". Set GITHUB_TOKEN or GH_TOKEN to raise the limit"
+	}
+	return msg
+}
+
+// githubToken is the token sent to the GitHub API, from GITHUB_TOKEN or
+// GH_TOKEN.
+func githubToken() string {
+	for _, k := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if v := strings.TrimSpace(os.Getenv(k)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// apiLimits remembers exhausted rate limits per API base, so the remaining
+// lookups of a run fail at once instead of each asking GitHub again.
+var apiLimits = struct {
+	sync.Mutex
+	m map[string]*RateLimitError
+}{m: map[string]*RateLimitError{}}
+
+func rateLimited(base string) *RateLimitError {
+	apiLimits.Lock()
+	defer apiLimits.Unlock()
+	e := apiLimits.m[base]
+	if e == nil || time.Now().After(e.Reset) {
+		delete(apiLimits.m, base)
+		return nil
+	}
+	return e
+}
+
+// noteRateLimit records the limit state GitHub reports on resp. It returns
+// the error to report when resp was refused because of the limit.
+func noteRateLimit(base string, resp *http.Response, authenticated bool) *RateLimitError {
+	h := resp.Header
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	hasRemaining := err == nil
+	e := &RateLimitError{Authenticated: authenticated}
+	e.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		e.Reset = time.Unix(reset, 0)
+	}
+
+	refused := resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests
+	if refused {
+		// Secondary limits come with Retry-After instead.
+		if s, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+			e.Reset = time.Now().Add(time.Duration(s) * time.Second)
+		} else if !hasRemaining || remaining > 0 {
+			return nil
+		}
+	} else if !hasRemaining || remaining > 0 {
+		return nil
+	}
+
+	if !e.Reset.IsZero() {
+		apiLimits.Lock()
+		apiLimits.m[base] = e
+		apiLimits.Unlock()
+	}
+	if !refused {
+		return nil
+	}
+	return e
+}
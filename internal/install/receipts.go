@@ -0,0 +1,114 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// record writes r. A receipt that cannot be written does not undo the
+// install, so it is only a warning.
+func record(r receipt.Receipt, emit events.Sink) {
+	if err := receipt.Write(r); err != nil {
+		emit.Warn("Could not write the install receipt for " + r.Name + ": " + err.Error())
+	}
+}
+
+// keepSource fills r's source and digest from the earlier receipt for the
+// same version, for installs that reuse files without downloading them.
+func keepSource(r receipt.Receipt) receipt.Receipt {
+	if old, ok, _ := receipt.Load(r.Kind, r.Name); ok && old.Version == r.Version {
+		r.Source, r.SHA256 = old.Source, old.SHA256
+	}
+	return r
+}
+
+// installedStatus reports what is installed for tool name. A receipt
+// whose files are all in place is taken as is; otherwise probe runs the
+// command found on PATH, and the files a stale receipt lists are returned
+// in Missing.
+func installedStatus(name, exe string, probe func() (version, path string, ok bool)) ToolStatus {
+	st := ToolStatus{}
+	if rc, ok, _ := receipt.Load(receipt.Tool, name); ok {
+		st.Missing = rc.Missing()
+		if path := receiptCommand(rc, exe); path != "" && len(st.Missing) == 0 {
+			st.Present, st.Path = true, path
+			st.CurrentVersion, st.CurrentOK = rc.Version, rc.Version != ""
+			st.Managed, st.InstalledAt = true, rc.Time()
+			return st
+		}
+	}
+	st.CurrentVersion, st.Path, st.CurrentOK = probe()
+	st.Present = st.Path != ""
+	return st
+}
+
+// receiptCommand returns the command named exe among rc's paths.
+func receiptCommand(rc receipt.Receipt, exe string) string {
+	for _, p := range rc.Paths {
+		if filepath.Base(p) == exe {
+			return p
+		}
+	}
+	return ""
+}
+
+// adopt writes a receipt for a tool Apply found already up to date at dst
+// without an intact receipt, e.g. one installed before receipts existed.
+// Files nvimwiz did not place, such as a package manager's, are left alone.
+func adopt(t ToolSpec, version, path, dst string, emit events.Sink) {
+	if path != dst {
+		return
+	}
+	paths := []string{dst}
+	if t.keepsReleaseDir() {
+		root, err := toolDirRoot(t)
+		target, lerr := os.Readlink(dst)
+		if err != nil || lerr != nil {
+			return
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(dst), target)
+		}
+		rel, err := filepath.Rel(root, filepath.Clean(target))
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		paths = append(paths, filepath.Join(root, strings.Split(filepath.ToSlash(rel), "/")[0]))
+	}
+	record(keepSource(receipt.Receipt{Kind: receipt.Tool, Name: t.Name, Version: version, Paths: paths}), emit)
+}
+
+// recordNvimUse points the neovim tool receipt at v after ~/.local/bin/nvim
+// was switched to it, taking the source from v's own receipt.
+func recordNvimUse(v NvimVersion, link string) error {
+	rc := receipt.Receipt{Kind: receipt.Tool, Name: "neovim", Version: normalizeVersion(v.Tag), Paths: []string{link, v.Dir}}
+	if nv, ok, _ := receipt.Load(receipt.Neovim, v.Tag); ok {
+		rc.Source, rc.SHA256, rc.InstalledAt = nv.Source, nv.SHA256, nv.InstalledAt
+		if nv.Version != "" {
+			rc.Version = nv.Version
+		}
+	}
+	return receipt.Write(rc)
+}
+
+// forgetNvimVersion drops the receipts for a removed Neovim version: its
+// own, and the neovim tool receipt if that still lists its directory.
+func forgetNvimVersion(v NvimVersion) error {
+	if err := receipt.Remove(receipt.Neovim, v.Tag); err != nil {
+		return err
+	}
+	rc, ok, _ := receipt.Load(receipt.Tool, "neovim")
+	if !ok {
+		return nil
+	}
+	for _, p := range rc.Paths {
+		if filepath.Clean(p) == filepath.Clean(v.Dir) {
+			return receipt.Remove(receipt.Tool, "neovim")
+		}
+	}
+	return nil
+}
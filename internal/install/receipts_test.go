@@ -0,0 +1,159 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"nvimwiz/internal/receipt"
+)
+
+func TestInstalledStatusPrefersIntactReceipt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	bin := filepath.Join(t.TempDir(), "rg")
+	if err := os.WriteFile(bin, []byte("x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	probed := false
+	probe := func() (string, string, bool) { probed = true; return "13.0.0", "/usr/bin/rg", true }
+
+	if err := receipt.Write(receipt.Receipt{Kind: receipt.Tool, Name: "ripgrep", Version: "14.1.1", Paths: []string{bin}}); err != nil {
+		t.Fatal(err)
+	}
+	st := installedStatus("ripgrep", "rg", probe)
+	if probed || !st.Managed || st.Path != bin || st.CurrentVersion != "14.1.1" || !st.CurrentOK || len(st.Missing) != 0 {
+		t.Fatalf("intact receipt: probed=%v, status %+v", probed, st)
+	}
+
+	os.Remove(bin)
+	st = installedStatus("ripgrep", "rg", probe)
+	if !probed || st.Managed || st.Path != "/usr/bin/rg" || st.CurrentVersion != "13.0.0" {
+		t.Fatalf("stale receipt: probed=%v, status %+v", probed, st)
+	}
+	if !reflect.DeepEqual(st.Missing, []string{bin}) {
+		t.Fatalf("Missing = %v, want [%s]", st.Missing, bin)
+	}
+}
+
+func TestAdoptOnlyOwnPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	rg, _ := LookupTool("ripgrep")
+	dst := filepath.Join(t.TempDir(), "rg")
+
+	adopt(rg, "14.1.1", "/usr/bin/rg", dst, nil)
+	if _, ok, _ := receipt.Load(receipt.Tool, "ripgrep"); ok {
+		t.Fatal("adopted a command outside ~/.local/bin")
+	}
+	adopt(rg, "14.1.1", dst, dst, nil)
+	rc, ok, _ := receipt.Load(receipt.Tool, "ripgrep")
+	if !ok || rc.Version != "14.1.1" || !reflect.DeepEqual(rc.Paths, []string{dst}) || rc.Source != "" {
+		t.Fatalf("adopted receipt = %+v, %v", rc, ok)
+	}
+}
+
+func TestAdoptKeepsReleaseDir(t *testing.T) {
+	root := fakeNvimVersions(t, "v0.10.2")
+	nv, _ := LookupTool("neovim")
+	link, err := nvimLinkPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UseNvimVersion("v0.10.2"); err != nil {
+		t.Fatal(err)
+	}
+	receipt.Remove(receipt.Tool, "neovim")
+
+	adopt(nv, "0.10.2", link, link, nil)
+	rc, ok, _ := receipt.Load(receipt.Tool, "neovim")
+	if want := []string{link, filepath.Join(root, "v0.10.2")}; !ok || !reflect.DeepEqual(rc.Paths, want) {
+		t.Fatalf("adopted paths = %v, want %v", rc.Paths, want)
+	}
+}
+
+func TestNvimVersionReceipts(t *testing.T) {
+	root := fakeNvimVersions(t, "v0.9.5", "v0.10.2", "v0.11.0")
+	for _, tag := range []string{"v0.9.5", "v0.10.2", "v0.11.0"} {
+		rc := receipt.Receipt{Kind: receipt.Neovim, Name: tag, Version: normalizeVersion(tag), Source: "https://example.com/" + tag, Paths: []string{filepath.Join(root, tag)}}
+		if err := receipt.Write(rc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link, _ := nvimLinkPath()
+
+	if _, err := UseNvimVersion("v0.10.2"); err != nil {
+		t.Fatal(err)
+	}
+	rc, ok, _ := receipt.Load(receipt.Tool, "neovim")
+	if !ok || rc.Version != "0.10.2" || rc.Source != "https://example.com/v0.10.2" || !reflect.DeepEqual(rc.Paths, []string{link, filepath.Join(root, "v0.10.2")}) {
+		t.Fatalf("after use: %+v, %v", rc, ok)
+	}
+
+	if err := RemoveNvimVersion("v0.9.5"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := receipt.Load(receipt.Neovim, "v0.9.5"); ok {
+		t.Fatal("receipt of the removed version kept")
+	}
+	if _, ok, _ := receipt.Load(receipt.Tool, "neovim"); !ok {
+		t.Fatal("removing another version dropped the neovim receipt")
+	}
+
+	// A tool receipt left pointing at a version that is no longer active
+	// goes with it.
+	stale := rc
+	stale.Paths = []string{link, filepath.Join(root, "v0.11.0")}
+	if err := receipt.Write(stale); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := PruneNvimVersions(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"v0.11.0"}) {
+		t.Fatalf("pruned %v", removed)
+	}
+	if _, ok, _ := receipt.Load(receipt.Neovim, "v0.11.0"); ok {
+		t.Fatal("receipt of the pruned version kept")
+	}
+	if _, ok, _ := receipt.Load(receipt.Tool, "neovim"); ok {
+		t.Fatal("neovim receipt still lists the pruned directory")
+	}
+	if _, ok, _ := receipt.Load(receipt.Neovim, "v0.10.2"); !ok {
+		t.Fatal("receipt of the active version removed")
+	}
+}
+
+func TestStatusForServerReadsReceipt(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("PATH", "")
+	srv := ServerSpec{Name: "fake-ls", Binaries: []string{"fake-ls"}}
+	bin, err := LSPBinDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := filepath.Join(bin, srv.commandFile("fake-ls"))
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cmd, []byte("x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := filepath.Join(home, "pkg")
+	rc := receipt.Receipt{Kind: receipt.Server, Name: srv.Name, Paths: []string{cmd, pkg}}
+	if err := receipt.Write(rc); err != nil {
+		t.Fatal(err)
+	}
+	if st := StatusForServer(srv); st.Installed || !reflect.DeepEqual(st.Missing, []string{pkg}) {
+		t.Fatalf("stale receipt: %+v", st)
+	}
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if st := StatusForServer(srv); !st.Installed || !st.Managed || st.Path != cmd {
+		t.Fatalf("intact receipt: %+v", st)
+	}
+}
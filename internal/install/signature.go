@@ -0,0 +1,275 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
+
+// With Verify set to "signed", an asset is only installed when a detached
+// signature made by a pinned key covers it, either directly or through a
+// signed checksum list such as SHA256SUMS. Two formats are understood:
+// minisign (".minisig" next to the file, minisign public key) and
+// cosign-style blobs (".sig" holding a base64 signature over the file,
+// PEM-encoded ECDSA P-256 or Ed25519 public key).
+var signatureExts = []string{".minisig", ".sig"}
+
+// signingKey is a parsed public key.
+type signingKey struct {
+	// keyID is the minisign key ID; it is nil for PEM keys.
+	keyID []byte
+	pub   crypto.PublicKey
+	label string
+}
+
+func parseSigningKeys(texts []string) ([]signingKey, error) {
+	keys := []signingKey{}
+	for _, t := range texts {
+		k, err := parseSigningKey(t)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// parseSigningKey reads a minisign public key (the bare base64 line or the
+// whole .pub file) or a PEM "PUBLIC KEY" block.
+func parseSigningKey(text string) (signingKey, error) {
+	text = strings.TrimSpace(text)
+	if block, _ := pem.Decode([]byte(text)); block != nil {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return signingKey{}, fmt.Errorf("signing key: %w", err)
+		}
+		sum := sha256.Sum256(block.Bytes)
+		fp := hex.EncodeToString(sum[:8])
+		switch k := pub.(type) {
+		case *ecdsa.PublicKey:
+			return signingKey{pub: k, label: "ECDSA key " + fp}, nil
+		case ed25519.PublicKey:
+			return signingKey{pub: k, label: "Ed25519 key " + fp}, nil
+		}
+		return signingKey{}, fmt.Errorf("signing key: unsupported key type %T", pub)
+	}
+
+	line := ""
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return signingKey{}, errors.New("signing key: not a minisign or PEM public key")
+	}
+	return signingKey{
+		keyID: b[2:10],
+		pub:   ed25519.PublicKey(b[10:]),
+		label: fmt.Sprintf("minisign key %016X", binary.LittleEndian.Uint64(b[2:10])),
+	}, nil
+}
+
+// verifySignature checks the signature at sigPath over the file at dataPath
+// against keys and describes the key that made it.
+func verifySignature(keys []signingKey, sigPath, dataPath string) (string, error) {
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return "", err
+	}
+	if bytes.HasPrefix(sig, []byte("untrusted comment:")) {
+		return verifyMinisign(keys, string(sig), dataPath)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return "", fmt.Errorf("%s is not a minisign or base64 signature", filepath.Base(sigPath))
+	}
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(data)
+	for _, k := range keys {
+		switch pub := k.pub.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(pub, digest[:], raw) {
+				return k.label, nil
+			}
+		case ed25519.PublicKey:
+			if k.keyID == nil && ed25519.Verify(pub, data, raw) {
+				return k.label, nil
+			}
+		}
+	}
+	return "", errors.New("signature does not match any pinned key")
+}
+
+// verifyMinisign checks a minisign signature file, including the signature
+// over its trusted comment.
+func verifyMinisign(keys []signingKey, sig, dataPath string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(sig, "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.New("malformed minisign signature")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(b) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+	alg, keyID, s := string(b[:2]), b[2:10], b[10:]
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", errors.New("malformed minisign signature")
+	}
+
+	var key *signingKey
+	for i := range keys {
+		if bytes.Equal(keys[i].keyID, keyID) {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return "", fmt.Errorf("signed by minisign key %016X, which is not pinned", binary.LittleEndian.Uint64(keyID))
+	}
+	pub := key.pub.(ed25519.PublicKey)
+
+	var msg []byte
+	switch alg {
+	case "ED":
+		h, _ := blake2b.New512(nil)
+		f, err := os.Open(dataPath)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		msg = h.Sum(nil)
+	case "Ed":
+		if msg, err = os.ReadFile(dataPath); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported minisign algorithm %q", alg)
+	}
+	if !ed25519.Verify(pub, msg, s) {
+		return "", fmt.Errorf("minisign signature by %s does not match", key.label)
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pub, append(append([]byte{}, s...), trusted...), global) {
+		return "", errors.New("minisign trusted comment signature does not match")
+	}
+	return key.label + ", trusted comment: " + trusted, nil
+}
+
+// verifySigned requires a signature by one of keys over asset, or over a
+// checksum list that has the asset's sha256.
+func verifySigned(ctx context.Context, r remote, keyTexts []string, rel ghRelease, asset ghAsset, filePath string, emit events.Sink) error {
+	keys, err := parseSigningKeys(keyTexts)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no signing key is pinned for " + asset.Name)
+	}
+	dir := filepath.Dir(filePath)
+
+	for _, ext := range signatureExts {
+		sigAsset, ok := findAsset(rel, func(a ghAsset) bool { return a.Name == asset.Name+ext })
+		if !ok {
+			continue
+		}
+		sigPath, err := fetchReleaseFile(ctx, r, sigAsset, dir, emit)
+		if err != nil {
+			return err
+		}
+		by, err := verifySignature(keys, sigPath, filePath)
+		if err != nil {
+			return fmt.Errorf("signature verification failed for %s: %w", asset.Name, err)
+		}
+		commitDownload(r, sigAsset.BrowserDownloadURL, sigPath, emit)
+		signatureVerified(emit, asset.Name, sigAsset.Name, "Signature verified for "+asset.Name+" ("+by+")")
+		return nil
+	}
+
+	for _, list := range rel.Assets {
+		if !isChecksumList(list.Name) {
+			continue
+		}
+		for _, ext := range signatureExts {
+			sigAsset, ok := findAsset(rel, func(a ghAsset) bool { return a.Name == list.Name+ext })
+			if !ok {
+				continue
+			}
+			listPath, err := fetchReleaseFile(ctx, r, list, dir, emit)
+			if err != nil {
+				return err
+			}
+			sigPath, err := fetchReleaseFile(ctx, r, sigAsset, dir, emit)
+			if err != nil {
+				return err
+			}
+			by, err := verifySignature(keys, sigPath, listPath)
+			if err != nil {
+				return fmt.Errorf("signature verification failed for %s: %w", list.Name, err)
+			}
+			commitDownload(r, list.BrowserDownloadURL, listPath, emit)
+			commitDownload(r, sigAsset.BrowserDownloadURL, sigPath, emit)
+			m, err := parseChecksumFile(listPath)
+			if err != nil {
+				return err
+			}
+			expected := m[asset.Name]
+			if expected == "" {
+				break
+			}
+			got, err := receipt.FileSHA256(filePath)
+			if err != nil {
+				return err
+			}
+			if !strings.EqualFold(got, expected) {
+				return fmt.Errorf("checksum verification failed for %s (sha256 %s, expected %s from signed %s)", asset.Name, got, expected, list.Name)
+			}
+			signatureVerified(emit, asset.Name, sigAsset.Name, "Signature verified for "+asset.Name+" (sha256 listed in "+list.Name+", "+by+")")
+			return nil
+		}
+	}
+	return errors.New("no signature found for " + asset.Name + " (verify is \"signed\")")
+}
+
+func fetchReleaseFile(ctx context.Context, r remote, a ghAsset, dir string, emit events.Sink) (string, error) {
+	p := filepath.Join(dir, a.Name)
+	emit.Log("Downloading " + a.Name)
+	if err := downloadFile(ctx, r, a.BrowserDownloadURL, p, emit); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+func signatureVerified(emit events.Sink, file, source, msg string) {
+	emit.Emit(events.Event{Kind: events.SignatureVerified, File: file, Source: source, Message: msg})
+}
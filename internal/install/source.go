@@ -0,0 +1,149 @@
+package install
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultGitHubAPI is the API releases are looked up through unless a
+// Source or NVIMWIZ_GITHUB_API_URL points elsewhere.
+const DefaultGitHubAPI = "https://api.github.com"
+
+// Source says where a tool's releases come from. Empty fields fall back to
+// the NVIMWIZ_GITHUB_API_URL, NVIMWIZ_DOWNLOAD_REWRITES and
+// NVIMWIZ_DOWNLOAD_MIRRORS environment variables, then to GitHub itself.
+type Source struct {
+	// APIBase is the GitHub API root, e.g. a GitHub Enterprise
+	// "https://ghe.example.com/api/v3".
+	APIBase string
+	// Rewrites maps download URL prefixes to replacements; the longest
+	// matching prefix wins.
+	Rewrites map[string]string
+	// Mirrors are tried in order when a download fails. Each replaces the
+	// scheme and host of the original URL, so "https://mirror.example/gh"
+	// serves ".../gh/neovim/neovim/releases/download/...".
+	Mirrors []string
+	// TokenEnv names the environment variable holding the token for an
+	// APIBase other than api.github.com; empty means
+	// NVIMWIZ_GITHUB_API_TOKEN. GITHUB_TOKEN and GH_TOKEN are only ever
+	// sent to api.github.com.
+	TokenEnv string
+}
+
+// remote is the resolved source and HTTP client one install talks to.
+type remote struct {
+	client *http.Client
+	src    Source
+	// offline is set when client answers from a bundle.
+	offline bool
+}
+
+func (o Options) remote() remote {
+	if dir := o.bundleDir(); dir != "" {
+		return remote{client: bundleClient(dir), offline: true}
+	}
+	c := o.HTTPClient
+	if c == nil {
+		c = httpClient
+	}
+	return remote{client: c, src: o.Source.withEnv()}
+}
+
+func (o Options) bundleDir() string {
+	if d := strings.TrimSpace(o.Bundle); d != "" {
+		return d
+	}
+	return strings.TrimSpace(os.Getenv("NVIMWIZ_BUNDLE"))
+}
+
+func (s Source) withEnv() Source {
+	out := Source{APIBase: s.APIBase, Rewrites: s.Rewrites, Mirrors: s.Mirrors, TokenEnv: s.TokenEnv}
+	if strings.TrimSpace(out.APIBase) == "" {
+		out.APIBase = os.Getenv("NVIMWIZ_GITHUB_API_URL")
+	}
+	if len(out.Rewrites) == 0 {
+		out.Rewrites = parseRewrites(os.Getenv("NVIMWIZ_DOWNLOAD_REWRITES"))
+	}
+	if len(out.Mirrors) == 0 {
+		out.Mirrors = splitList(os.Getenv("NVIMWIZ_DOWNLOAD_MIRRORS"))
+	}
+	return out
+}
+
+// parseRewrites reads "from=to,from2=to2".
+func parseRewrites(v string) map[string]string {
+	m := map[string]string{}
+	for _, item := range splitList(v) {
+		from, to, ok := strings.Cut(item, "=")
+		if ok && strings.TrimSpace(from) != "" {
+			m[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+	return m
+}
+
+func splitList(v string) []string {
+	out := []string{}
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (r remote) apiBase() string {
+	if b := strings.TrimRight(strings.TrimSpace(r.src.APIBase), "/"); b != "" {
+		return b
+	}
+	return DefaultGitHubAPI
+}
+
+// apiToken returns the token to send to apiBase and the variable it came
+// from, so a github.com token never reaches a mirror or GHE host.
+func (r remote) apiToken() (token, from string) {
+	if u, err := url.Parse(r.apiBase()); err == nil && strings.EqualFold(u.Host, "api.github.com") {
+		return githubToken(), "GITHUB_TOKEN/GH_TOKEN"
+	}
+	from = strings.TrimSpace(r.src.TokenEnv)
+	if from == "" {
+		from = "NVIMWIZ_GITHUB_API_TOKEN"
+	}
+	return strings.TrimSpace(os.Getenv(from)), from
+}
+
+// rewrite applies the longest matching rewrite rule to rawURL.
+func (r remote) rewrite(rawURL string) string {
+	best := ""
+	for from := range r.src.Rewrites {
+		if strings.HasPrefix(rawURL, from) && len(from) > len(best) {
+			best = from
+		}
+	}
+	if best == "" {
+		return rawURL
+	}
+	return r.src.Rewrites[best] + strings.TrimPrefix(rawURL, best)
+}
+
+// downloadURLs lists where rawURL is fetched from, in order: the rewritten
+// URL, then each mirror.
+func (r remote) downloadURLs(rawURL string) []string {
+	urls := []string{r.rewrite(rawURL)}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return urls
+	}
+	for _, m := range r.src.Mirrors {
+		alt := strings.TrimRight(m, "/") + u.EscapedPath()
+		if u.RawQuery != "" {
+			alt += "?" + u.RawQuery
+		}
+		if alt != urls[0] {
+			urls = append(urls, alt)
+		}
+	}
+	return urls
+}
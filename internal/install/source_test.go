@@ -0,0 +1,170 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAPIToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "gh-secret")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("NVIMWIZ_GITHUB_API_TOKEN", "")
+	t.Setenv("GHE_TOKEN", "ghe-secret")
+	tests := []struct {
+		name string
+		src  Source
+		want string
+	}{
+		{"github.com", Source{}, "gh-secret"},
+		{"github.com explicit", Source{APIBase: "https://api.github.com/"}, "gh-secret"},
+		{"ghe without token", Source{APIBase: "https://ghe.example.com/api/v3"}, ""},
+		{"ghe with tokenEnv", Source{APIBase: "https://ghe.example.com/api/v3", TokenEnv: "GHE_TOKEN"}, "ghe-secret"},
+		{"lookalike host", Source{APIBase: "https://api.github.com.evil.example"}, ""},
+	}
+	for _, tt := range tests {
+		r := remote{src: tt.src}
+		if got, _ := r.apiToken(); got != tt.want {
+			t.Errorf("%s: token = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadURLs(t *testing.T) {
+	const asset = "https://github.com/neovim/neovim/releases/download/v0.10.2/nvim-linux-x86_64.tar.gz"
+	tests := []struct {
+		name string
+		src  Source
+		url  string
+		want []string
+	}{
+		{"plain", Source{}, asset, []string{asset}},
+		{
+			"longest rewrite wins",
+			Source{Rewrites: map[string]string{
+				"https://github.com/":        "https://m1.example/gh/",
+				"https://github.com/neovim/": "https://m2.example/nvim/",
+				"https://example.com/":       "https://unused.example/",
+			}},
+			asset,
+			[]string{"https://m2.example/nvim/neovim/releases/download/v0.10.2/nvim-linux-x86_64.tar.gz"},
+		},
+		{
+			"rewrite then mirrors",
+			Source{
+				Rewrites: map[string]string{"https://github.com/": "https://m1.example/gh/"},
+				Mirrors:  []string{"https://mirror.example/gh/", "https://m1.example/gh", "https://other.example"},
+			},
+			asset,
+			[]string{
+				"https://m1.example/gh/neovim/neovim/releases/download/v0.10.2/nvim-linux-x86_64.tar.gz",
+				"https://mirror.example/gh/neovim/neovim/releases/download/v0.10.2/nvim-linux-x86_64.tar.gz",
+				"https://other.example/neovim/neovim/releases/download/v0.10.2/nvim-linux-x86_64.tar.gz",
+			},
+		},
+		{
+			"mirror keeps escaping and query",
+			Source{Mirrors: []string{"https://mirror.example"}},
+			"https://example.com/dist/a%20b.tar.gz?x=1",
+			[]string{"https://example.com/dist/a%20b.tar.gz?x=1", "https://mirror.example/dist/a%20b.tar.gz?x=1"},
+		},
+	}
+	for _, tt := range tests {
+		got := remote{src: tt.src}.downloadURLs(tt.url)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: downloadURLs = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSourceFromEnv(t *testing.T) {
+	t.Setenv("NVIMWIZ_GITHUB_API_URL", "")
+	t.Setenv("NVIMWIZ_DOWNLOAD_REWRITES", " , no-equals-sign, =https://x.example/, https://a.example/ = https://b.example/ ,https://c.example/=https://d.example/?t=1,")
+	t.Setenv("NVIMWIZ_DOWNLOAD_MIRRORS", " ,https://m1.example ,, https://m2.example/ ,")
+
+	src := Source{}.withEnv()
+	wantRewrites := map[string]string{
+		"https://a.example/": "https://b.example/",
+		"https://c.example/": "https://d.example/?t=1",
+	}
+	if len(src.Rewrites) != len(wantRewrites) {
+		t.Errorf("Rewrites = %q, want %q", src.Rewrites, wantRewrites)
+	}
+	for from, to := range wantRewrites {
+		if src.Rewrites[from] != to {
+			t.Errorf("Rewrites[%q] = %q, want %q", from, src.Rewrites[from], to)
+		}
+	}
+	if got := strings.Join(src.Mirrors, " "); got != "https://m1.example https://m2.example/" {
+		t.Errorf("Mirrors = %q", src.Mirrors)
+	}
+	if got := (remote{src: src}).apiBase(); got != DefaultGitHubAPI {
+		t.Errorf("apiBase = %q", got)
+	}
+
+	// Profile settings win over the environment.
+	own := Source{Rewrites: map[string]string{"https://e.example/": "https://f.example/"}, Mirrors: []string{"https://m3.example"}}.withEnv()
+	if len(own.Rewrites) != 1 || len(own.Mirrors) != 1 || own.Mirrors[0] != "https://m3.example" {
+		t.Errorf("profile source replaced by the environment: %+v", own)
+	}
+
+	t.Setenv("NVIMWIZ_DOWNLOAD_REWRITES", ",,")
+	t.Setenv("NVIMWIZ_DOWNLOAD_MIRRORS", "")
+	if src := (Source{}).withEnv(); len(src.Rewrites) != 0 || len(src.Mirrors) != 0 {
+		t.Errorf("empty lists parsed to %+v", src)
+	}
+}
+
+func TestDownloadFileUsesMirrors(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var mu sync.Mutex
+	hits := []string{}
+	serve := func(name string, ok bool) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, name+" "+r.URL.Path)
+			mu.Unlock()
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("from " + name))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	primary, broken, mirror := serve("primary", false), serve("broken", false), serve("mirror", true)
+
+	const asset = "https://github.com/o/r/releases/download/v1/a.tar.gz"
+	r := remote{client: http.DefaultClient, src: Source{
+		Rewrites: map[string]string{"https://github.com/": primary.URL + "/gh/"},
+		Mirrors:  []string{broken.URL, mirror.URL + "/m"},
+	}}
+	dst := filepath.Join(t.TempDir(), "a.tar.gz")
+	if err := downloadFile(context.Background(), r, asset, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(dst); string(b) != "from mirror" {
+		t.Fatalf("downloaded %q", b)
+	}
+	want := "primary /gh/o/r/releases/download/v1/a.tar.gz|broken /o/r/releases/download/v1/a.tar.gz|mirror /m/o/r/releases/download/v1/a.tar.gz"
+	mu.Lock()
+	got := strings.Join(hits, "|")
+	mu.Unlock()
+	if got != want {
+		t.Fatalf("requests %q, want %q", got, want)
+	}
+
+	r.src.Mirrors = r.src.Mirrors[:1]
+	var he *httpError
+	err := downloadFile(context.Background(), r, asset, filepath.Join(t.TempDir(), "a.tar.gz"), nil)
+	if !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+		t.Fatalf("all sources failing: err = %v, want the last 404", err)
+	}
+}
@@ -2,6 +2,8 @@ package install
 
 import (
 	"context"
+	"strings"
+	"time"
 )
 
 type ToolStatus struct {
@@ -9,45 +11,71 @@ type ToolStatus struct {
 	Path           string
 	CurrentVersion string
 	CurrentOK      bool
-	LatestVersion  string
-	LatestTag      string
-	LatestOK       bool
-	Error          string
+	// Managed is set when Path and CurrentVersion come from the install
+	// receipt nvimwiz wrote at InstalledAt rather than from PATH.
+	Managed     bool
+	InstalledAt time.Time
+	// Missing lists files the tool's receipt records that are gone, so
+	// the receipt was not trusted.
+	Missing []string
+
+	// Pinned is the version spec from the profile; empty means latest.
+	Pinned string
+	// Target* describe the release Apply would install: the latest one, or
+	// the pinned one when Pinned is set.
+	TargetVersion string
+	TargetTag     string
+	TargetOK      bool
+	Error         string
+
+	// Asset is the release file Apply would download for this system.
+	// AssetNote says why when the C library decided the choice, or why no
+	// build fits, in which case Asset is empty.
+	Asset     string
+	AssetNote string
+}
+
+// UpToDate reports whether the installed version is the one Apply would
+// install.
+func (st ToolStatus) UpToDate() bool {
+	return st.CurrentOK && st.TargetOK && st.CurrentVersion != "" && st.CurrentVersion == st.TargetVersion
 }
 
-func StatusForFeature(ctx context.Context, featureID string) (ToolStatus, bool) {
-	switch featureID {
-	case "install.neovim":
-		return status(ctx, "nvim", []string{"--version"}, "neovim", "neovim"), true
-	case "install.ripgrep":
-		return status(ctx, "rg", []string{"--version"}, "BurntSushi", "ripgrep"), true
-	case "install.fd":
-		return status(ctx, "fd", []string{"--version"}, "sharkdp", "fd"), true
-	default:
+// StatusForFeature reports the installed and target versions of a tool.
+// opts.Version is the pinned spec from the profile ("" means latest).
+func StatusForFeature(ctx context.Context, featureID string, opts Options) (ToolStatus, bool) {
+	if featureID == NodeFeatureID {
+		return nodeStatus(ctx, opts), true
+	}
+	t, ok := ToolByFeature(featureID)
+	if !ok {
 		return ToolStatus{}, false
 	}
+	return status(ctx, t, opts), true
 }
 
-func status(ctx context.Context, command string, args []string, owner, repo string) ToolStatus {
+func status(ctx context.Context, t ToolSpec, opts Options) ToolStatus {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	cur, path, ok := installedCommandVersion(ctx, command, args...)
-	st := ToolStatus{
-		Present:        path != "",
-		Path:           path,
-		CurrentVersion: cur,
-		CurrentOK:      ok,
+	st := installedStatus(t.Name, t.ExeName(), func() (string, string, bool) { return t.installedVersion(ctx) })
+	if v := strings.TrimSpace(opts.Version); v != "" && !strings.EqualFold(v, "latest") {
+		st.Pinned = v
 	}
 
-	rel, err := fetchLatestRelease(ctx, owner, repo)
+	rel, err := fetchRelease(ctx, opts.remote(), t.Owner, t.Repo, t.releaseSpec(opts.Version))
 	if err != nil {
 		st.Error = err.Error()
 		return st
 	}
-	st.LatestTag = rel.TagName
-	st.LatestVersion = normalizeVersion(rel.TagName)
-	st.LatestOK = st.LatestVersion != ""
+	st.TargetTag = rel.TagName
+	st.TargetVersion = t.releaseVersion(rel)
+	st.TargetOK = st.TargetVersion != ""
+	if a, note, err := t.pickAsset(rel); err == nil {
+		st.Asset, st.AssetNote = a.Name, note
+	} else {
+		st.AssetNote = err.Error()
+	}
 	return st
 }
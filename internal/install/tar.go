@@ -3,13 +3,16 @@ package install
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
+
+	"github.com/ulikunitz/xz"
 )
 
-func extractTarGz(src, dest string) (string, error) {
+// extractTarGz unpacks a gzip-compressed tarball into dest and returns its
+// top-level entry.
+func extractTarGz(ctx context.Context, src, dest string) (string, error) {
 	f, err := os.Open(src)
 	if err != nil {
 		return "", err
@@ -20,10 +23,31 @@ func extractTarGz(src, dest string) (string, error) {
 		return "", err
 	}
 	defer gz.Close()
-	tr := tar.NewReader(gz)
+	return extractTar(ctx, src, gz, dest)
+}
 
-	top := ""
+// extractTarXz unpacks an xz-compressed tarball into dest and returns its
+// top-level entry.
+func extractTarXz(ctx context.Context, src, dest string) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	xr, err := xz.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	return extractTar(ctx, src, xr, dest)
+}
+
+func extractTar(ctx context.Context, src string, r io.Reader, dest string) (string, error) {
+	x := newExtractor(src, dest)
+	tr := tar.NewReader(r)
 	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		h, err := tr.Next()
 		if err == io.EOF {
 			break
@@ -31,50 +55,53 @@ func extractTarGz(src, dest string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		name := filepath.Clean(h.Name)
-		name = strings.TrimPrefix(name, string(filepath.Separator))
-		if name == "." || name == "" {
-			continue
+		name, err := x.entry(h.Name)
+		if err != nil {
+			return "", err
 		}
-		if strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		if name == "" {
 			continue
 		}
-		if top == "" {
-			parts := strings.Split(name, string(filepath.Separator))
-			if len(parts) > 0 {
-				top = parts[0]
-			}
-		}
-
-		target := filepath.Join(dest, name)
 		switch h.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(h.Mode)); err != nil {
-				return "", err
-			}
+			err = x.dir(name, os.FileMode(h.Mode))
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return "", err
-			}
-			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode))
-			if err != nil {
-				return "", err
-			}
-			if _, err := io.Copy(out, tr); err != nil {
-				out.Close()
-				return "", err
-			}
-			out.Close()
+			err = x.file(name, os.FileMode(h.Mode), h.Size, tr)
 		case tar.TypeSymlink:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return "", err
-			}
-			_ = os.Remove(target)
-			if err := os.Symlink(h.Linkname, target); err != nil {
-				return "", err
-			}
-		default:
+			err = x.symlink(name, h.Linkname)
 		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return x.top, nil
+}
+
+// gunzipFile decompresses a single gzip-compressed binary to dst.
+func gunzipFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(gz, maxExtractBytes+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if n > maxExtractBytes {
+		return &ExtractError{Archive: src, Err: ErrArchiveTooLarge}
 	}
-	return top, nil
+	return nil
 }
@@ -0,0 +1,375 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"nvimwiz/internal/sysinfo"
+)
+
+// InstallStyle says how an unpacked release ends up on disk.
+type InstallStyle int
+
+const (
+	// CopyBinary copies the tool's binary into ~/.local/bin.
+	CopyBinary InstallStyle = iota
+	// SymlinkDir keeps the whole release under ~/.local/<DirRoot>/<tag> and
+	// links its binary into ~/.local/bin.
+	SymlinkDir
+	// AppImage stores the release's AppImage as
+	// ~/.local/<DirRoot>/<tag>/<BinDir>/<Binary> and links it into
+	// ~/.local/bin.
+	AppImage
+)
+
+// ToolSpec declares a tool installed from GitHub releases. Every install.*
+// catalog feature with a spec is installed, previewed and status-checked
+// from it.
+type ToolSpec struct {
+	// Name is the key used in a profile's pinned versions.
+	Name      string
+	FeatureID string
+	Title     string
+
+	Owner string
+	Repo  string
+
+	// TagPrefix is prepended to pinned versions that lack it, for projects
+	// tagging releases like "jq-1.7.1".
+	TagPrefix string
+
+	// Binary is the command name, without .exe on Windows.
+	Binary      string
+	VersionArgs []string
+	// VersionPattern extracts the version from the command's output when
+	// it is not the second word of the first line.
+	VersionPattern *regexp.Regexp
+
+	// Archive is the preferred asset file extension: ".tar.gz", ".tar.xz",
+	// ".zip", ".gz" for a single compressed binary, ".appimage", or "" for
+	// a bare binary.
+	Archive string
+	// Assets lists asset name fragments per "GOOS/GOARCH", most preferred
+	// first. An asset matches when it contains one of them and ends in
+	// Archive, or else in another tarball or zip extension; bare binaries,
+	// and fragments that are a whole file name, must match exactly.
+	Assets map[string][]string
+	// Libc is the C library a Linux fragment's builds need: "glibc", or
+	// "glibc 2.31" for a minimum version, or "musl" for static musl
+	// builds. Fragments not listed run on any Linux. Fragments that do not
+	// fit the host are passed over.
+	Libc map[string]string
+
+	Style InstallStyle
+	// DirRoot and BinDir locate the binary for SymlinkDir installs:
+	// ~/.local/<DirRoot>/<tag>/<BinDir>/<Binary>.
+	DirRoot string
+	BinDir  string
+
+	// SigningKeys are public keys the project signs its releases with,
+	// checked when Verify is "signed".
+	SigningKeys []string
+}
+
+func (t ToolSpec) signingKeys(opts Options) []string {
+	keys := append([]string{}, t.SigningKeys...)
+	return append(keys, opts.SigningKeys...)
+}
+
+var tools = []ToolSpec{
+	{
+		Name:        "neovim",
+		FeatureID:   "install.neovim",
+		Title:       "Neovim",
+		Owner:       "neovim",
+		Repo:        "neovim",
+		Binary:      "nvim",
+		VersionArgs: []string{"--version"},
+		Archive:     ".tar.gz",
+		// Releases before v0.10.4 used nvim-linux64 and nvim-macos-*. The
+		// AppImages are used when a release lacks the tarball.
+		Assets: map[string][]string{
+			"linux/amd64":  {"nvim-linux-x86_64.tar.gz", "nvim-linux64.tar.gz", "nvim-linux-x86_64.appimage", "nvim.appimage"},
+			"linux/arm64":  {"nvim-linux-arm64.tar.gz", "nvim-linux-arm64.appimage"},
+			"darwin/amd64": {"nvim-macos-x86_64.tar.gz"},
+			"darwin/arm64": {"nvim-macos-arm64.tar.gz"},
+		},
+		// The release notes require glibc 2.31 for the Linux builds; there
+		// is no musl build.
+		Libc: map[string]string{
+			"nvim-linux-x86_64.tar.gz":   "glibc 2.31",
+			"nvim-linux64.tar.gz":        "glibc",
+			"nvim-linux-x86_64.appimage": "glibc 2.31",
+			"nvim.appimage":              "glibc",
+			"nvim-linux-arm64.tar.gz":    "glibc 2.31",
+			"nvim-linux-arm64.appimage":  "glibc 2.31",
+		},
+		Style:   SymlinkDir,
+		DirRoot: "nvim",
+		BinDir:  "bin",
+	},
+	{
+		Name:        "ripgrep",
+		FeatureID:   "install.ripgrep",
+		Title:       "ripgrep",
+		Owner:       "BurntSushi",
+		Repo:        "ripgrep",
+		Binary:      "rg",
+		VersionArgs: []string{"--version"},
+		Archive:     ".tar.gz",
+		Assets: map[string][]string{
+			"linux/amd64":   {"x86_64-unknown-linux-musl"},
+			"linux/arm64":   {"aarch64-unknown-linux-musl", "arm64-unknown-linux-musl", "aarch64-unknown-linux-gnu"},
+			"darwin/amd64":  {"x86_64-apple-darwin"},
+			"darwin/arm64":  {"aarch64-apple-darwin", "arm64-apple-darwin"},
+			"windows/amd64": {"x86_64-pc-windows-msvc"},
+		},
+		Libc: map[string]string{
+			"x86_64-unknown-linux-musl":  "musl",
+			"aarch64-unknown-linux-musl": "musl",
+			"arm64-unknown-linux-musl":   "musl",
+			"aarch64-unknown-linux-gnu":  "glibc",
+		},
+		Style: CopyBinary,
+	},
+	{
+		Name:        "fd",
+		FeatureID:   "install.fd",
+		Title:       "fd",
+		Owner:       "sharkdp",
+		Repo:        "fd",
+		Binary:      "fd",
+		VersionArgs: []string{"--version"},
+		Archive:     ".tar.gz",
+		Assets: map[string][]string{
+			"linux/amd64":   {"x86_64-unknown-linux-gnu", "x86_64-unknown-linux-musl"},
+			"linux/arm64":   {"aarch64-unknown-linux-gnu", "arm64-unknown-linux-gnu", "aarch64-unknown-linux-musl"},
+			"darwin/amd64":  {"x86_64-apple-darwin"},
+			"darwin/arm64":  {"aarch64-apple-darwin", "arm64-apple-darwin"},
+			"windows/amd64": {"x86_64-pc-windows-msvc"},
+		},
+		Libc: map[string]string{
+			"x86_64-unknown-linux-gnu":   "glibc",
+			"aarch64-unknown-linux-gnu":  "glibc",
+			"arm64-unknown-linux-gnu":    "glibc",
+			"x86_64-unknown-linux-musl":  "musl",
+			"aarch64-unknown-linux-musl": "musl",
+		},
+		Style: CopyBinary,
+	},
+	{
+		Name:           "lazygit",
+		FeatureID:      "install.lazygit",
+		Title:          "lazygit",
+		Owner:          "jesseduffield",
+		Repo:           "lazygit",
+		Binary:         "lazygit",
+		VersionArgs:    []string{"--version"},
+		VersionPattern: regexp.MustCompile(`(?:^|[\s,])version=([0-9][^,\s]*)`),
+		Archive:        ".tar.gz",
+		Assets: map[string][]string{
+			"linux/amd64":  {"_Linux_x86_64"},
+			"linux/arm64":  {"_Linux_arm64"},
+			"darwin/amd64": {"_Darwin_x86_64"},
+			"darwin/arm64": {"_Darwin_arm64"},
+		},
+		Style: CopyBinary,
+	},
+	{
+		Name:           "fzf",
+		FeatureID:      "install.fzf",
+		Title:          "fzf",
+		Owner:          "junegunn",
+		Repo:           "fzf",
+		Binary:         "fzf",
+		VersionArgs:    []string{"--version"},
+		VersionPattern: regexp.MustCompile(`^\s*v?([0-9][0-9A-Za-z.+-]*)`),
+		Archive:        ".tar.gz",
+		Assets: map[string][]string{
+			"linux/amd64":  {"-linux_amd64"},
+			"linux/arm64":  {"-linux_arm64"},
+			"darwin/amd64": {"-darwin_amd64"},
+			"darwin/arm64": {"-darwin_arm64"},
+		},
+		Style: CopyBinary,
+	},
+	{
+		Name:        "tree-sitter",
+		FeatureID:   "install.treesitter",
+		Title:       "tree-sitter CLI",
+		Owner:       "tree-sitter",
+		Repo:        "tree-sitter",
+		Binary:      "tree-sitter",
+		VersionArgs: []string{"--version"},
+		Archive:     ".gz",
+		Assets: map[string][]string{
+			"linux/amd64":   {"tree-sitter-linux-x64.gz"},
+			"linux/arm64":   {"tree-sitter-linux-arm64.gz"},
+			"darwin/amd64":  {"tree-sitter-macos-x64.gz"},
+			"darwin/arm64":  {"tree-sitter-macos-arm64.gz"},
+			"windows/amd64": {"tree-sitter-windows-x64.gz"},
+		},
+		Libc: map[string]string{
+			"tree-sitter-linux-x64.gz":   "glibc",
+			"tree-sitter-linux-arm64.gz": "glibc",
+		},
+		Style: CopyBinary,
+	},
+	{
+		Name:           "jq",
+		FeatureID:      "install.jq",
+		Title:          "jq",
+		Owner:          "jqlang",
+		Repo:           "jq",
+		TagPrefix:      "jq-",
+		Binary:         "jq",
+		VersionArgs:    []string{"--version"},
+		VersionPattern: regexp.MustCompile(`jq-([0-9][0-9A-Za-z.+-]*)`),
+		Archive:        "",
+		Assets: map[string][]string{
+			"linux/amd64":   {"jq-linux-amd64"},
+			"linux/arm64":   {"jq-linux-arm64"},
+			"darwin/amd64":  {"jq-macos-amd64"},
+			"darwin/arm64":  {"jq-macos-arm64"},
+			"windows/amd64": {"jq-windows-amd64.exe"},
+		},
+		Style: CopyBinary,
+	},
+}
+
+// Tools returns every registered tool in install order.
+func Tools() []ToolSpec {
+	out := make([]ToolSpec, len(tools))
+	copy(out, tools)
+	return out
+}
+
+// LookupTool finds a tool by its profile name, e.g. "ripgrep".
+func LookupTool(name string) (ToolSpec, bool) {
+	for _, t := range tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return ToolSpec{}, false
+}
+
+// ToolByFeature finds the tool behind an install.* feature.
+func ToolByFeature(featureID string) (ToolSpec, bool) {
+	for _, t := range tools {
+		if t.FeatureID == featureID {
+			return t, true
+		}
+	}
+	return ToolSpec{}, false
+}
+
+// ToolForFeature maps an install.* feature to the tool name used as key in
+// a profile's pinned versions.
+func ToolForFeature(featureID string) (string, bool) {
+	if featureID == NodeFeatureID {
+		return "node", true
+	}
+	t, ok := ToolByFeature(featureID)
+	return t.Name, ok
+}
+
+// ExeName is the binary's file name on this OS.
+func (t ToolSpec) ExeName() string {
+	if runtime.GOOS == "windows" {
+		return t.Binary + ".exe"
+	}
+	return t.Binary
+}
+
+// installedVersion reports the version of the tool's command on PATH.
+func (t ToolSpec) installedVersion(ctx context.Context) (version string, path string, ok bool) {
+	return installedCommandVersion(ctx, t.VersionPattern, t.Binary, t.VersionArgs...)
+}
+
+// releaseSpec turns a pinned version into the tag to look up.
+func (t ToolSpec) releaseSpec(version string) string {
+	v := strings.TrimSpace(version)
+	if t.TagPrefix == "" || v == "" || strings.EqualFold(v, "latest") || isReleaseChannel(v) || strings.HasPrefix(v, t.TagPrefix) {
+		return v
+	}
+	return t.TagPrefix + normalizeVersion(v)
+}
+
+// releaseVersion is the version rel installs, read from its tag with
+// TagPrefix removed ("jq-1.7.1") before falling back to its name and notes.
+func (t ToolSpec) releaseVersion(rel ghRelease) string {
+	if t.TagPrefix != "" {
+		rel.TagName = strings.TrimPrefix(rel.TagName, t.TagPrefix)
+	}
+	return releaseVersion(rel)
+}
+
+// pickAsset chooses the asset to install on this system. The note says why
+// when the host's C library decided it; an *UnsupportedError means only
+// builds this system cannot run were found.
+func (t ToolSpec) pickAsset(rel ghRelease) (asset ghAsset, note string, err error) {
+	return t.pickAssetFor(rel, runtime.GOOS+"/"+runtime.GOARCH, hostLibc())
+}
+
+// pickAssetFor is pickAsset for a platform such as "linux/amd64" whose C
+// library is host.
+func (t ToolSpec) pickAssetFor(rel ghRelease, platform string, host sysinfo.Libc) (asset ghAsset, note string, err error) {
+	skipped := []string{}
+	for _, frag := range t.Assets[platform] {
+		a, ok := t.assetFor(rel, frag)
+		if !ok {
+			continue
+		}
+		req := t.Libc[frag]
+		if fits, why := libcFits(req, host); !fits {
+			skipped = append(skipped, a.Name+" ("+why+")")
+			continue
+		}
+		return a, libcNote(req, host, skipped), nil
+	}
+	if len(skipped) > 0 {
+		name := t.Title
+		if name == "" {
+			name = t.Repo
+		}
+		return ghAsset{}, "", &UnsupportedError{Tool: name, Reason: strings.Join(skipped, "; ")}
+	}
+	return ghAsset{}, "", errors.New(t.Repo + " asset not found")
+}
+
+// assetFor finds the asset matching one fragment.
+func (t ToolSpec) assetFor(rel ghRelease, frag string) (ghAsset, bool) {
+	want := t.Archive
+	if t.Style == AppImage {
+		want = archiveAppImage
+	}
+	var other *ghAsset
+	for i, a := range rel.Assets {
+		if a.Name == frag {
+			return a, true
+		}
+		if want == archiveNone || !strings.Contains(a.Name, frag) {
+			continue
+		}
+		switch kind := archiveType(a.Name); {
+		case kind == want:
+			return a, true
+		case other == nil && (kind == archiveTarGz || kind == archiveTarXz || kind == archiveZip):
+			other = &rel.Assets[i]
+		}
+	}
+	if other != nil {
+		return *other, true
+	}
+	return ghAsset{}, false
+}
+
+// keepsReleaseDir reports whether t is installed into a versioned
+// directory that ~/.local/bin links into.
+func (t ToolSpec) keepsReleaseDir() bool {
+	return t.Style == SymlinkDir || t.Style == AppImage
+}
@@ -0,0 +1,149 @@
+package install
+
+import (
+	"errors"
+	"testing"
+
+	"nvimwiz/internal/sysinfo"
+)
+
+func testRelease(names ...string) ghRelease {
+	rel := ghRelease{}
+	for _, n := range names {
+		rel.Assets = append(rel.Assets, ghAsset{Name: n})
+	}
+	return rel
+}
+
+// Asset lists as published by each project, trimmed to the names that
+// matter for picking plus a few that must not be picked.
+var toolReleases = map[string]ghRelease{
+	"neovim": testRelease(
+		"nvim-linux-arm64.appimage", "nvim-linux-arm64.appimage.zsync", "nvim-linux-arm64.tar.gz",
+		"nvim-linux-x86_64.appimage", "nvim-linux-x86_64.appimage.zsync", "nvim-linux-x86_64.tar.gz",
+		"nvim-macos-arm64.tar.gz", "nvim-macos-x86_64.tar.gz", "nvim-win64.msi", "nvim-win64.zip", "shasum.txt",
+	),
+	"neovim-0.9": testRelease(
+		"nvim-linux64.tar.gz", "nvim-linux64.tar.gz.sha256sum", "nvim-macos.tar.gz",
+		"nvim-win64.zip", "nvim.appimage", "nvim.appimage.sha256sum", "nvim.appimage.zsync",
+	),
+	"neovim-appimage-only": testRelease("nvim-linux-x86_64.appimage", "nvim-macos-arm64.tar.gz"),
+	"ripgrep": testRelease(
+		"ripgrep-14.1.1-aarch64-apple-darwin.tar.gz", "ripgrep-14.1.1-aarch64-apple-darwin.tar.gz.sha256",
+		"ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz", "ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz.sha256",
+		"ripgrep-14.1.1-i686-unknown-linux-gnu.tar.gz", "ripgrep-14.1.1-x86_64-apple-darwin.tar.gz",
+		"ripgrep-14.1.1-x86_64-pc-windows-gnu.zip", "ripgrep-14.1.1-x86_64-pc-windows-msvc.zip",
+		"ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz", "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"ripgrep_14.1.1-1_amd64.deb",
+	),
+	"fd": testRelease(
+		"fd-v10.2.0-aarch64-apple-darwin.tar.gz", "fd-v10.2.0-aarch64-unknown-linux-gnu.tar.gz",
+		"fd-v10.2.0-aarch64-unknown-linux-musl.tar.gz", "fd-v10.2.0-i686-unknown-linux-gnu.tar.gz",
+		"fd-v10.2.0-x86_64-apple-darwin.tar.gz", "fd-v10.2.0-x86_64-pc-windows-msvc.zip",
+		"fd-v10.2.0-x86_64-unknown-linux-gnu.tar.gz", "fd-v10.2.0-x86_64-unknown-linux-musl.tar.gz",
+		"fd_10.2.0_amd64.deb", "fd-musl_10.2.0_amd64.deb",
+	),
+	"lazygit": testRelease(
+		"checksums.txt", "lazygit_0.44.1_Darwin_arm64.tar.gz", "lazygit_0.44.1_Darwin_x86_64.tar.gz",
+		"lazygit_0.44.1_Linux_32-bit.tar.gz", "lazygit_0.44.1_Linux_arm64.tar.gz", "lazygit_0.44.1_Linux_armv6.tar.gz",
+		"lazygit_0.44.1_Linux_x86_64.tar.gz", "lazygit_0.44.1_Windows_x86_64.zip",
+	),
+	"fzf": testRelease(
+		"fzf-0.56.3-darwin_amd64.zip", "fzf-0.56.3-darwin_arm64.zip", "fzf-0.56.3-linux_amd64.tar.gz",
+		"fzf-0.56.3-linux_arm64.tar.gz", "fzf-0.56.3-linux_armv7.tar.gz", "fzf-0.56.3-windows_amd64.zip",
+		"fzf_0.56.3_checksums.txt",
+	),
+	"tree-sitter": testRelease(
+		"tree-sitter-linux-arm.gz", "tree-sitter-linux-arm64.gz", "tree-sitter-linux-x64.gz", "tree-sitter-linux-x86.gz",
+		"tree-sitter-macos-arm64.gz", "tree-sitter-macos-x64.gz", "tree-sitter-windows-arm64.gz",
+		"tree-sitter-windows-x64.gz", "tree-sitter-windows-x86.gz", "web-tree-sitter.tar.gz",
+	),
+	"jq": testRelease(
+		"jq-1.7.1.tar.gz", "jq-1.7.1.zip", "jq-linux-amd64", "jq-linux-arm64", "jq-linux-armhf", "jq-linux64",
+		"jq-macos-amd64", "jq-macos-arm64", "jq-osx-amd64", "jq-windows-amd64.exe", "jq-win64.exe", "sha256sum.txt",
+	),
+}
+
+func TestToolAssets(t *testing.T) {
+	tests := []struct {
+		tool, platform string
+		host           sysinfo.Libc
+		release        string // key in toolReleases; defaults to tool
+		want           string // "" means *UnsupportedError
+	}{
+		{"neovim", "linux/amd64", glibc235, "", "nvim-linux-x86_64.tar.gz"},
+		{"neovim", "linux/arm64", glibc235, "", "nvim-linux-arm64.tar.gz"},
+		{"neovim", "darwin/amd64", sysinfo.Libc{}, "", "nvim-macos-x86_64.tar.gz"},
+		{"neovim", "darwin/arm64", sysinfo.Libc{}, "", "nvim-macos-arm64.tar.gz"},
+		{"neovim", "linux/amd64", glibc228, "", ""},
+		{"neovim", "linux/amd64", musl124, "", ""},
+		{"neovim", "linux/amd64", glibc228, "neovim-0.9", "nvim-linux64.tar.gz"},
+		{"neovim", "linux/amd64", glibc235, "neovim-appimage-only", "nvim-linux-x86_64.appimage"},
+		{"ripgrep", "linux/amd64", glibc235, "", "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz"},
+		{"ripgrep", "linux/amd64", musl124, "", "ripgrep-14.1.1-x86_64-unknown-linux-musl.tar.gz"},
+		{"ripgrep", "linux/arm64", glibc235, "", "ripgrep-14.1.1-aarch64-unknown-linux-gnu.tar.gz"},
+		{"ripgrep", "linux/arm64", musl124, "", ""},
+		{"ripgrep", "darwin/amd64", sysinfo.Libc{}, "", "ripgrep-14.1.1-x86_64-apple-darwin.tar.gz"},
+		{"ripgrep", "darwin/arm64", sysinfo.Libc{}, "", "ripgrep-14.1.1-aarch64-apple-darwin.tar.gz"},
+		{"ripgrep", "windows/amd64", sysinfo.Libc{}, "", "ripgrep-14.1.1-x86_64-pc-windows-msvc.zip"},
+		{"fd", "linux/amd64", glibc235, "", "fd-v10.2.0-x86_64-unknown-linux-gnu.tar.gz"},
+		{"fd", "linux/amd64", musl124, "", "fd-v10.2.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"fd", "linux/arm64", glibc235, "", "fd-v10.2.0-aarch64-unknown-linux-gnu.tar.gz"},
+		{"fd", "linux/arm64", musl124, "", "fd-v10.2.0-aarch64-unknown-linux-musl.tar.gz"},
+		{"fd", "darwin/amd64", sysinfo.Libc{}, "", "fd-v10.2.0-x86_64-apple-darwin.tar.gz"},
+		{"fd", "darwin/arm64", sysinfo.Libc{}, "", "fd-v10.2.0-aarch64-apple-darwin.tar.gz"},
+		{"fd", "windows/amd64", sysinfo.Libc{}, "", "fd-v10.2.0-x86_64-pc-windows-msvc.zip"},
+		{"lazygit", "linux/amd64", musl124, "", "lazygit_0.44.1_Linux_x86_64.tar.gz"},
+		{"lazygit", "linux/arm64", glibc235, "", "lazygit_0.44.1_Linux_arm64.tar.gz"},
+		{"lazygit", "darwin/amd64", sysinfo.Libc{}, "", "lazygit_0.44.1_Darwin_x86_64.tar.gz"},
+		{"lazygit", "darwin/arm64", sysinfo.Libc{}, "", "lazygit_0.44.1_Darwin_arm64.tar.gz"},
+		{"fzf", "linux/amd64", glibc235, "", "fzf-0.56.3-linux_amd64.tar.gz"},
+		{"fzf", "linux/arm64", musl124, "", "fzf-0.56.3-linux_arm64.tar.gz"},
+		{"fzf", "darwin/amd64", sysinfo.Libc{}, "", "fzf-0.56.3-darwin_amd64.zip"},
+		{"fzf", "darwin/arm64", sysinfo.Libc{}, "", "fzf-0.56.3-darwin_arm64.zip"},
+		{"tree-sitter", "linux/amd64", glibc235, "", "tree-sitter-linux-x64.gz"},
+		{"tree-sitter", "linux/amd64", musl124, "", ""},
+		{"tree-sitter", "linux/arm64", glibc228, "", "tree-sitter-linux-arm64.gz"},
+		{"tree-sitter", "darwin/amd64", sysinfo.Libc{}, "", "tree-sitter-macos-x64.gz"},
+		{"tree-sitter", "darwin/arm64", sysinfo.Libc{}, "", "tree-sitter-macos-arm64.gz"},
+		{"tree-sitter", "windows/amd64", sysinfo.Libc{}, "", "tree-sitter-windows-x64.gz"},
+		{"jq", "linux/amd64", musl124, "", "jq-linux-amd64"},
+		{"jq", "linux/arm64", glibc235, "", "jq-linux-arm64"},
+		{"jq", "darwin/amd64", sysinfo.Libc{}, "", "jq-macos-amd64"},
+		{"jq", "darwin/arm64", sysinfo.Libc{}, "", "jq-macos-arm64"},
+		{"jq", "windows/amd64", sysinfo.Libc{}, "", "jq-windows-amd64.exe"},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		spec, ok := LookupTool(tt.tool)
+		if !ok {
+			t.Fatalf("no tool %q", tt.tool)
+		}
+		covered[tt.tool+" "+tt.platform] = true
+		key := tt.release
+		if key == "" {
+			key = tt.tool
+		}
+		a, _, err := spec.pickAssetFor(toolReleases[key], tt.platform, tt.host)
+		if tt.want == "" {
+			var ue *UnsupportedError
+			if !errors.As(err, &ue) {
+				t.Errorf("%s %s on %s: picked %q, %v; want *UnsupportedError", tt.tool, tt.platform, tt.host, a.Name, err)
+			}
+			continue
+		}
+		if err != nil || a.Name != tt.want {
+			t.Errorf("%s %s on %s: picked %q, %v; want %q", tt.tool, tt.platform, tt.host, a.Name, err, tt.want)
+		}
+	}
+
+	// Every platform a tool is registered for needs a pinned pick.
+	for _, spec := range Tools() {
+		for platform := range spec.Assets {
+			if !covered[spec.Name+" "+platform] {
+				t.Errorf("no asset pinned for %s on %s", spec.Name, platform)
+			}
+		}
+	}
+}
@@ -3,92 +3,120 @@ package install
 import (
 	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
-)
-
-func verifyAssetIfPossible(ctx context.Context, policy string, rel ghRelease, asset ghAsset, filePath string, log func(string)) error {
-	p := strings.ToLower(strings.TrimSpace(policy))
-	if p == "off" {
-		return nil
-	}
 
-	cands := []string{
-		asset.Name + ".sha256",
-		asset.Name + ".sha256sum",
-		asset.Name + ".sha256.txt",
-	}
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/receipt"
+)
 
-	cs, ok := findAsset(rel, func(a ghAsset) bool {
-		for _, c := range cands {
-			if a.Name == c {
-				return true
-			}
-		}
-		return false
-	})
+// checksumSource is one place a release may publish the sha256 of an asset:
+// a per-asset file, a combined checksum file, or the release notes.
+type checksumSource struct {
+	name string
+	// asset is the file to download; it is empty for the release notes.
+	asset ghAsset
+	// single is set for per-asset files, whose only hash may come without a
+	// file name.
+	single bool
+}
 
-	if !ok {
-		if p == "require" {
-			return errors.New("checksum not available for " + asset.Name)
+// checksumSources lists where the expected hash of asset is looked for, in
+// order of preference.
+func checksumSources(rel ghRelease, asset ghAsset) []checksumSource {
+	out := []checksumSource{}
+	for _, suffix := range []string{".sha256", ".sha256sum", ".sha256.txt"} {
+		if a, ok := findAsset(rel, func(a ghAsset) bool { return a.Name == asset.Name+suffix }); ok {
+			out = append(out, checksumSource{name: a.Name, asset: a, single: true})
 		}
-		if log != nil {
-			log("Checksum not available for " + asset.Name + ", skipping verification")
+	}
+	for _, a := range rel.Assets {
+		if isChecksumList(a.Name) {
+			out = append(out, checksumSource{name: a.Name, asset: a})
 		}
-		return nil
 	}
+	if strings.Contains(rel.Body, "```") || strings.Contains(rel.Body, "~~~") {
+		out = append(out, checksumSource{name: "release notes"})
+	}
+	return out
+}
 
-	csPath := filepath.Join(filepath.Dir(filePath), cs.Name)
-	if log != nil {
-		log("Downloading checksum " + cs.Name)
+func (s checksumSource) load(ctx context.Context, r remote, rel ghRelease, dir string, emit events.Sink) (map[string]string, error) {
+	if s.asset.Name == "" {
+		return notesChecksums(rel.Body), nil
 	}
-	if err := downloadFile(ctx, cs.BrowserDownloadURL, csPath); err != nil {
-		if p == "require" {
-			return err
-		}
-		if log != nil {
-			log("Checksum download failed, skipping verification")
-		}
-		return nil
+	p := filepath.Join(dir, s.asset.Name)
+	emit.Log("Downloading checksum " + s.asset.Name)
+	if err := downloadFile(ctx, r, s.asset.BrowserDownloadURL, p, emit); err != nil {
+		return nil, err
 	}
-
-	m, err := parseChecksumFile(csPath)
+	m, err := parseChecksumFile(p)
 	if err != nil {
-		if p == "require" {
-			return err
-		}
-		if log != nil {
-			log("Checksum parse failed, skipping verification")
-		}
+		return nil, err
+	}
+	commitDownload(r, s.asset.BrowserDownloadURL, p, emit)
+	return m, nil
+}
+
+// verifyAssetIfPossible checks filePath against the first checksum source
+// that has an entry for asset. With policy "require" a missing checksum is
+// an error; "off" skips verification and "signed" requires a signature by
+// one of keys instead.
+func verifyAssetIfPossible(ctx context.Context, r remote, policy string, keys []string, rel ghRelease, asset ghAsset, filePath string, emit events.Sink) error {
+	p := strings.ToLower(strings.TrimSpace(policy))
+	switch p {
+	case "off":
 		return nil
+	case "signed":
+		return verifySigned(ctx, r, keys, rel, asset, filePath, emit)
 	}
-	expected := m[asset.Name]
-	if expected == "" {
-		if len(m) == 1 {
-			for _, v := range m {
-				expected = v
+
+	var loadErr error
+	for _, src := range checksumSources(rel, asset) {
+		m, err := src.load(ctx, r, rel, filepath.Dir(filePath), emit)
+		if err != nil {
+			loadErr = err
+			continue
+		}
+		expected := m[asset.Name]
+		if expected == "" && src.single {
+			expected = m[""]
+			if expected == "" && len(m) == 1 {
+				for _, v := range m {
+					expected = v
+				}
 			}
 		}
-	}
-	if expected == "" {
-		if p == "require" {
-			return errors.New("checksum mismatch data for " + asset.Name)
+		if expected == "" {
+			continue
 		}
-		if log != nil {
-			log("Checksum entry missing for " + asset.Name + ", skipping verification")
+
+		got, err := receipt.FileSHA256(filePath)
+		if err != nil {
+			return err
 		}
+		if !strings.EqualFold(got, expected) {
+			return fmt.Errorf("checksum verification failed for %s (sha256 %s, expected %s from %s)", asset.Name, got, expected, src.name)
+		}
+		emit.Emit(events.Event{Kind: events.ChecksumVerified, File: asset.Name, Source: src.name, Message: "Checksum verified for " + asset.Name + " (from " + src.name + ")"})
 		return nil
 	}
 
-	got, err := sha256File(filePath)
-	if err != nil {
-		return err
-	}
-	if strings.EqualFold(strings.TrimSpace(got), strings.TrimSpace(expected)) {
-		if log != nil {
-			log("Checksum verified for " + asset.Name)
+	if p == "require" {
+		if loadErr != nil {
+			return fmt.Errorf("checksum not available for %s: %w", asset.Name, loadErr)
 		}
+		return errors.New("checksum not available for " + asset.Name)
+	}
+	if loadErr != nil {
+		checksumSkipped(emit, asset.Name, "Checksum download failed, skipping verification")
 		return nil
 	}
-	return errors.New("checksum verification failed for " + asset.Name)
+	checksumSkipped(emit, asset.Name, "Checksum not available for "+asset.Name+", skipping verification")
+	return nil
+}
+
+func checksumSkipped(emit events.Sink, file, msg string) {
+	emit.Emit(events.Event{Kind: events.ChecksumSkipped, File: file, Message: msg})
 }
@@ -3,6 +3,7 @@ package install
 import (
 	"context"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -13,7 +14,10 @@ func normalizeVersion(v string) string {
 	return v
 }
 
-func installedCommandVersion(ctx context.Context, command string, args ...string) (version string, path string, ok bool) {
+// installedCommandVersion runs command and reads its version from the
+// output: the first submatch of pattern, or the second word of the first
+// line when pattern is nil ("ripgrep 14.1.1", "NVIM v0.10.2").
+func installedCommandVersion(ctx context.Context, pattern *regexp.Regexp, command string, args ...string) (version string, path string, ok bool) {
 	path, err := exec.LookPath(command)
 	if err != nil {
 		return "", "", false
@@ -33,19 +37,29 @@ func installedCommandVersion(ctx context.Context, command string, args ...string
 	if err != nil {
 		return "", path, false
 	}
+	version, ok = parseCommandVersion(pattern, string(out))
+	return version, path, ok
+}
 
-	lines := strings.Split(string(out), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+// parseCommandVersion reads a version from a command's output the way
+// installedCommandVersion describes.
+func parseCommandVersion(pattern *regexp.Regexp, out string) (string, bool) {
+	if pattern != nil {
+		if m := pattern.FindStringSubmatch(out); len(m) > 1 {
+			return normalizeVersion(m[1]), true
 		}
+		return "", false
+	}
+
+	for _, line := range strings.Split(out, "\n") {
 		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
 		if len(fields) >= 2 {
-			return normalizeVersion(fields[1]), path, true
+			return normalizeVersion(fields[1]), true
 		}
 		break
 	}
-
-	return "", path, false
+	return "", false
 }
@@ -0,0 +1,87 @@
+package install
+
+import "testing"
+
+func TestToolVersionOutput(t *testing.T) {
+	tests := []struct {
+		tool, out string
+		want      string
+	}{
+		{"neovim", "NVIM v0.10.2\nBuild type: Release\nLuaJIT 2.1.1713484068\n", "0.10.2"},
+		{"neovim", "NVIM v0.11.0-dev-1234+g0a1b2c3d4\n", "0.11.0-dev-1234+g0a1b2c3d4"},
+		{"ripgrep", "ripgrep 14.1.1\n\nfeatures:+pcre2\nsimd(compile):+SSE2,-SSSE3\n", "14.1.1"},
+		{"fd", "fd 10.2.0\n", "10.2.0"},
+		{"lazygit", "commit=fbdb2a8, build date=2024-09-22T08:24:42Z, build source=binaryRelease, version=0.44.1, os=linux, arch=amd64, git version=2.43.0\n", "0.44.1"},
+		{"lazygit", "version=0.40.2, commit=5e388e2\n", "0.40.2"},
+		{"fzf", "0.56.3 (b7248d4)\n", "0.56.3"},
+		{"fzf", "0.44.1 (brew)\n", "0.44.1"},
+		{"fzf", "0.57.0-devel (HEAD)\n", "0.57.0-devel"},
+		{"tree-sitter", "tree-sitter 0.24.4 (fc8c1863e2e5724a0c40bb6e6cfc8631bfe5908b)\n", "0.24.4"},
+		{"tree-sitter", "tree-sitter 0.22.6\n", "0.22.6"},
+		{"jq", "jq-1.7.1\n", "1.7.1"},
+		{"jq", "jq-1.6\n", "1.6"},
+	}
+	for _, tt := range tests {
+		spec, ok := LookupTool(tt.tool)
+		if !ok {
+			t.Fatalf("no tool %q", tt.tool)
+		}
+		if got, ok := parseCommandVersion(spec.VersionPattern, tt.out); !ok || got != tt.want {
+			t.Errorf("%s: version of %q = %q, %v; want %q", tt.tool, tt.out, got, ok, tt.want)
+		}
+	}
+
+	for _, tool := range []string{"lazygit", "jq"} {
+		spec, _ := LookupTool(tool)
+		if got, ok := parseCommandVersion(spec.VersionPattern, "command not found\n"); ok {
+			t.Errorf("%s: read version %q from unrelated output", tool, got)
+		}
+	}
+}
+
+func TestReleaseSpec(t *testing.T) {
+	jq, _ := LookupTool("jq")
+	rg, _ := LookupTool("ripgrep")
+	tests := []struct {
+		spec    ToolSpec
+		version string
+		want    string
+	}{
+		{jq, "1.7.1", "jq-1.7.1"},
+		{jq, "v1.7.1", "jq-1.7.1"},
+		{jq, "jq-1.7.1", "jq-1.7.1"},
+		{jq, "", ""},
+		{jq, "latest", "latest"},
+		{rg, "14.1.1", "14.1.1"},
+		{rg, "v14.1.1", "v14.1.1"},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.releaseSpec(tt.version); got != tt.want {
+			t.Errorf("%s.releaseSpec(%q) = %q, want %q", tt.spec.Name, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestToolReleaseVersion(t *testing.T) {
+	jq, _ := LookupTool("jq")
+	rg, _ := LookupTool("ripgrep")
+	nvim, _ := LookupTool("neovim")
+	tests := []struct {
+		spec ToolSpec
+		rel  ghRelease
+		want string
+	}{
+		{jq, ghRelease{TagName: "jq-1.7.1"}, "1.7.1"},
+		{jq, ghRelease{TagName: "jq-1.7.1", Name: "jq 1.7.1"}, "1.7.1"},
+		{jq, ghRelease{TagName: "jq-1.6"}, "1.6"},
+		{rg, ghRelease{TagName: "14.1.1"}, "14.1.1"},
+		{nvim, ghRelease{TagName: "v0.10.2"}, "0.10.2"},
+		{nvim, ghRelease{TagName: "nightly", Body: "```\nNVIM v0.11.0-dev-1234+g0a1b2c3d4\n```"}, "0.11.0-dev-1234+g0a1b2c3d4"},
+		{nvim, ghRelease{TagName: "stable", Name: "Nvim 0.10.2"}, "0.10.2"},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.releaseVersion(tt.rel); got != tt.want {
+			t.Errorf("%s.releaseVersion(%q) = %q, want %q", tt.spec.Name, tt.rel.TagName, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package install
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxZipLinkTarget bounds how much of a zip symlink entry is read as its
+// target.
+const maxZipLinkTarget = 4096
+
+// extractZip unpacks a zip archive into dest and returns its top-level
+// entry.
+func extractZip(ctx context.Context, src, dest string) (string, error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	x := newExtractor(src, dest)
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		name, err := x.entry(f.Name)
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			continue
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+			err = x.dir(name, mode|0o755)
+		case mode&os.ModeSymlink != 0:
+			err = extractZipLink(x, f, name)
+		case mode.IsRegular():
+			err = extractZipFile(x, f, name)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return x.top, nil
+}
+
+func extractZipFile(x *extractor, f *zip.File, name string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// Archives made on Windows carry no permissions; keep them readable.
+	mode := f.Mode()
+	if mode.Perm() == 0 {
+		mode |= 0o644
+	}
+	return x.file(name, mode, int64(f.UncompressedSize64), rc)
+}
+
+func extractZipLink(x *extractor, f *zip.File, name string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, maxZipLinkTarget))
+	if err != nil {
+		return err
+	}
+	return x.symlink(name, string(target))
+}
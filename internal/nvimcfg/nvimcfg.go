@@ -9,6 +9,8 @@ import (
 
 	"nvimwiz/internal/assets"
 	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/install"
 	"nvimwiz/internal/profile"
 )
 
@@ -35,7 +37,7 @@ func ConfigDir() (string, error) {
 	return ConfigDirForAppName("nvim")
 }
 
-func Write(p profile.Profile, cat catalog.Catalog, log func(string)) error {
+func Write(p profile.Profile, cat catalog.Catalog, emit events.Sink) error {
 	root, err := ConfigDirForProfile(p)
 	if err != nil {
 		return err
@@ -48,10 +50,7 @@ func Write(p profile.Profile, cat catalog.Catalog, log func(string)) error {
 		return err
 	}
 
-	ignore := map[string]bool{
-		filepath.Join("lua", "nvimwiz", "user.lua"): true,
-	}
-	if err := copyDir(src, root, ignore); err != nil {
+	if err := copyDir(src, root, assetIgnore()); err != nil {
 		return err
 	}
 
@@ -74,22 +73,35 @@ func Write(p profile.Profile, cat catalog.Catalog, log func(string)) error {
 		}
 	}
 
-	headless := "lua require(\"nvimwiz.loader\")\n"
-	if err := os.WriteFile(filepath.Join(root, "nvimwiz_headless_init.vim"), []byte(headless), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(root, headlessInitName), []byte(headlessInit), 0o644); err != nil {
 		return err
 	}
 
-	if log != nil {
-		log("Wrote Neovim config to " + root)
-	}
+	emit.Log("Wrote Neovim config to " + root)
 	return nil
 }
 
+const (
+	managedInit      = "vim.g.nvimwiz_managed = true\nrequire(\"nvimwiz.loader\")\n"
+	headlessInitName = "nvimwiz_headless_init.vim"
+	headlessInit     = "lua require(\"nvimwiz.loader\")\n"
+)
+
+func assetIgnore() map[string]bool {
+	return map[string]bool{
+		filepath.Join("lua", "nvimwiz", "user.lua"): true,
+	}
+}
+
+func isManagedInit(b []byte) bool {
+	return strings.Contains(string(b), "vim.g.nvimwiz_managed = true")
+}
+
 func writeInitLua(root string) error {
 	initPath := filepath.Join(root, "init.lua")
-	newInit := "vim.g.nvimwiz_managed = true\nrequire(\"nvimwiz.loader\")\n"
+	newInit := managedInit
 	if existingBytes, err := os.ReadFile(initPath); err == nil {
-		if strings.Contains(string(existingBytes), "vim.g.nvimwiz_managed = true") {
+		if isManagedInit(existingBytes) {
 			return os.WriteFile(initPath, []byte(newInit), 0o644)
 		}
 		timestamp := time.Now().Format("20060102-150405")
@@ -200,6 +212,17 @@ func buildConfigLua(p profile.Profile, cat catalog.Catalog) (string, error) {
 		b.WriteString("\t\t" + key + " = " + luaBool(lsp[key]) + ",\n")
 	}
 	b.WriteString("\t},\n")
+	b.WriteString("\tpath_prepend = {\n")
+	if p.Features["lsp.servers"] {
+		if dir, err := install.LSPBinDir(); err == nil {
+			b.WriteString("\t\t" + luaString(dir) + ",\n")
+		}
+		// npm servers start through "#!/usr/bin/env node".
+		if dir := install.NodeBinDir(); dir != "" {
+			b.WriteString("\t\t" + luaString(dir) + ",\n")
+		}
+	}
+	b.WriteString("\t},\n")
 	b.WriteString("\tmodules = {\n")
 	for _, mod := range modules {
 		b.WriteString("\t\t" + luaString(mod) + ",\n")
@@ -0,0 +1,109 @@
+package nvimcfg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"nvimwiz/internal/assets"
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/profile"
+)
+
+const (
+	ChangeCreate    = "create"
+	ChangeOverwrite = "overwrite"
+	ChangeUnchanged = "unchanged"
+)
+
+type FileChange struct {
+	Path   string
+	Action string
+}
+
+type WritePlan struct {
+	Root  string
+	Files []FileChange
+
+	// InitBackup is true when Write would move an existing, unmanaged
+	// init.lua aside to init.lua.bak-<timestamp> before replacing it.
+	InitBackup bool
+}
+
+// PlanWrite reports the files Write would create or overwrite for p
+// without touching the filesystem.
+func PlanWrite(p profile.Profile, cat catalog.Catalog) (WritePlan, error) {
+	root, err := ConfigDirForProfile(p)
+	if err != nil {
+		return WritePlan{}, err
+	}
+	src, err := assets.FindNvimAssets()
+	if err != nil {
+		return WritePlan{}, err
+	}
+
+	plan := WritePlan{Root: root}
+	ignore := assetIgnore()
+	err = filepath.WalkDir(src, func(path string, entry os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if ignore[rel] {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		plan.Files = append(plan.Files, planFile(filepath.Join(root, rel), b))
+		return nil
+	})
+	if err != nil {
+		return WritePlan{}, err
+	}
+
+	cfgLua, err := buildConfigLua(p, cat)
+	if err != nil {
+		return WritePlan{}, err
+	}
+	plan.Files = append(plan.Files, planFile(filepath.Join(root, "lua", "nvimwiz", "generated", "config.lua"), []byte(cfgLua)))
+
+	if p.ConfigMode == "managed" {
+		initPath := filepath.Join(root, "init.lua")
+		plan.Files = append(plan.Files, planFile(initPath, []byte(managedInit)))
+		if b, err := os.ReadFile(initPath); err == nil && !isManagedInit(b) {
+			plan.InitBackup = true
+		}
+	}
+
+	plan.Files = append(plan.Files, planFile(filepath.Join(root, headlessInitName), []byte(headlessInit)))
+	return plan, nil
+}
+
+func planFile(path string, want []byte) FileChange {
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return FileChange{Path: path, Action: ChangeCreate}
+		}
+		return FileChange{Path: path, Action: ChangeOverwrite}
+	}
+	if bytes.Equal(cur, want) {
+		return FileChange{Path: path, Action: ChangeUnchanged}
+	}
+	return FileChange{Path: path, Action: ChangeOverwrite}
+}
@@ -31,6 +31,103 @@ type Profile struct {
 	Choices     map[string]string `json:"choices"`
 	Target      string            `json:"target"`
 	AppName     string            `json:"appName"`
+
+	// Versions pins the release installed per tool name from the install
+	// registry ("neovim", "ripgrep", "lazygit", ...): "latest", "stable",
+	// "nightly" or a tag such as "v0.10.2".
+	// Missing tools install the latest release.
+	Versions map[string]string `json:"versions,omitempty"`
+
+	// Sources overrides where a tool's releases come from, keyed by tool
+	// name; the "*" entry applies to every tool.
+	Sources map[string]ReleaseSource `json:"sources,omitempty"`
+
+	// SigningKeys adds public keys (minisign, or PEM for cosign-style
+	// signatures) accepted when Verify is "signed", keyed by tool name;
+	// the "*" entry applies to every tool.
+	SigningKeys map[string][]string `json:"signingKeys,omitempty"`
+
+	// Bundle installs from a directory made by "nvimwiz bundle create"
+	// instead of the network.
+	Bundle string `json:"bundle,omitempty"`
+
+	// NvimVersion binds a safe build's launcher to one Neovim release under
+	// ~/.local/nvim instead of the nvim found on PATH.
+	NvimVersion string `json:"nvimVersion,omitempty"`
+
+	// TaskPolicy applies to every apply task; TaskPolicies overrides it per
+	// task ID (for example "install.neovim" or "config.lazysync"). A nil
+	// TaskPolicy keeps every task's defaults.
+	TaskPolicy   *TaskPolicy           `json:"taskPolicy,omitempty"`
+	TaskPolicies map[string]TaskPolicy `json:"taskPolicies,omitempty"`
+}
+
+// ReleaseSource points release lookups and downloads at a GitHub
+// Enterprise server, an artifact proxy or mirrors.
+type ReleaseSource struct {
+	// APIBase replaces https://api.github.com.
+	APIBase string `json:"apiBase,omitempty"`
+	// Rewrites maps download URL prefixes to replacements.
+	Rewrites map[string]string `json:"rewrites,omitempty"`
+	// Mirrors replace the scheme and host of a download URL and are tried
+	// in order when it fails.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// TokenEnv names the environment variable holding the API token for
+	// APIBase. The token itself never goes in the profile.
+	TokenEnv string `json:"tokenEnv,omitempty"`
+}
+
+func (rs *ReleaseSource) normalize() {
+	rs.APIBase = strings.TrimRight(strings.TrimSpace(rs.APIBase), "/")
+	rs.TokenEnv = strings.TrimSpace(rs.TokenEnv)
+	for from, to := range rs.Rewrites {
+		delete(rs.Rewrites, from)
+		if from = strings.TrimSpace(from); from != "" {
+			rs.Rewrites[from] = strings.TrimSpace(to)
+		}
+	}
+	mirrors := []string{}
+	for _, m := range rs.Mirrors {
+		if m = strings.TrimRight(strings.TrimSpace(m), "/"); m != "" {
+			mirrors = append(mirrors, m)
+		}
+	}
+	rs.Mirrors = mirrors
+}
+
+// TaskPolicy controls how long a task may run and how often it is retried.
+// Empty fields keep the task's built-in defaults.
+type TaskPolicy struct {
+	Timeout  string `json:"timeout,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+	Backoff  string `json:"backoff,omitempty"`
+	// RetryOn lists the error classes worth another attempt: "timeout",
+	// "network", "5xx", "any" or HTTP status codes such as "429".
+	RetryOn []string `json:"retryOn,omitempty"`
+}
+
+func (tp TaskPolicy) empty() bool {
+	return tp.Timeout == "" && tp.Attempts == 0 && tp.Backoff == "" && len(tp.RetryOn) == 0
+}
+
+// normalize tidies tp without dropping values it cannot use: the runner
+// ignores those and reports them, so a typo is not lost silently.
+func (tp *TaskPolicy) normalize() {
+	tp.Timeout = strings.TrimSpace(tp.Timeout)
+	tp.Backoff = strings.TrimSpace(tp.Backoff)
+	if tp.Attempts < 0 {
+		tp.Attempts = 0
+	}
+	classes := []string{}
+	for _, c := range tp.RetryOn {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			classes = append(classes, c)
+		}
+	}
+	if len(classes) == 0 {
+		classes = nil
+	}
+	tp.RetryOn = classes
 }
 
 func Load(cat catalog.Catalog) (Profile, bool, error) {
@@ -185,6 +282,34 @@ func ListProfiles() ([]string, error) {
 	return out, nil
 }
 
+// NvimBinding is a saved profile whose safe-build launcher is bound to a
+// Neovim version.
+type NvimBinding struct {
+	Profile string
+	AppName string
+	// Spec is the profile's nvimVersion, e.g. "v0.10.2" or "stable".
+	Spec string
+}
+
+// NvimBindings returns the launcher bindings of all saved profiles.
+func NvimBindings(cat catalog.Catalog) ([]NvimBinding, error) {
+	names, err := ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	out := []NvimBinding{}
+	for _, name := range names {
+		p, _, err := LoadByName(name, cat)
+		if err != nil {
+			continue
+		}
+		if v := p.BoundNvimVersion(); v != "" {
+			out = append(out, NvimBinding{Profile: p.Name, AppName: p.EffectiveAppName(), Spec: v})
+		}
+	}
+	return out, nil
+}
+
 func Exists(name string) (bool, error) {
 	name = sanitizeProfileName(name)
 	if name == "" {
@@ -479,11 +604,58 @@ func (p *Profile) Normalize(cat catalog.Catalog) {
 	p.ConfigMode = mode
 
 	verify := strings.ToLower(strings.TrimSpace(p.Verify))
-	if verify != "require" && verify != "off" {
+	if verify != "require" && verify != "off" && verify != "signed" {
 		verify = "auto"
 	}
 	p.Verify = verify
 
+	for tool, v := range p.Versions {
+		v = strings.TrimSpace(v)
+		switch strings.ToLower(v) {
+		case "", "latest":
+			delete(p.Versions, tool)
+			continue
+		case "stable", "nightly":
+			v = strings.ToLower(v)
+		}
+		p.Versions[tool] = v
+	}
+
+	p.NvimVersion = strings.TrimSpace(p.NvimVersion)
+	switch strings.ToLower(p.NvimVersion) {
+	case "latest":
+		p.NvimVersion = ""
+	case "stable", "nightly":
+		p.NvimVersion = strings.ToLower(p.NvimVersion)
+	}
+
+	p.Bundle = strings.TrimSpace(p.Bundle)
+	for tool, rs := range p.Sources {
+		rs.normalize()
+		p.Sources[tool] = rs
+	}
+
+	for tool, keys := range p.SigningKeys {
+		out := []string{}
+		for _, k := range keys {
+			if k = strings.TrimSpace(k); k != "" {
+				out = append(out, k)
+			}
+		}
+		p.SigningKeys[tool] = out
+	}
+
+	if p.TaskPolicy != nil {
+		p.TaskPolicy.normalize()
+		if p.TaskPolicy.empty() {
+			p.TaskPolicy = nil
+		}
+	}
+	for id, tp := range p.TaskPolicies {
+		tp.normalize()
+		p.TaskPolicies[id] = tp
+	}
+
 	if p.Features == nil {
 		p.Features = map[string]bool{}
 	}
@@ -734,3 +906,53 @@ func ensureDefaultProfile(cat catalog.Catalog) error {
 	p.Normalize(cat)
 	return SaveAs("default", p)
 }
+
+// BoundNvimVersion returns the Neovim version a safe build's launcher is
+// bound to, or "" when it runs the nvim on PATH.
+func (p Profile) BoundNvimVersion() string {
+	if strings.ToLower(strings.TrimSpace(p.Target)) != "safe" {
+		return ""
+	}
+	return p.NvimVersion
+}
+
+// ToolVersion returns the pinned version spec for tool, or "" for latest.
+func (p Profile) ToolVersion(tool string) string {
+	return strings.TrimSpace(p.Versions[tool])
+}
+
+// ToolSource returns the release source for tool: its own entry in Sources
+// on top of the "*" entry.
+func (p Profile) ToolSource(tool string) ReleaseSource {
+	base, own := p.Sources["*"], p.Sources[tool]
+	out := ReleaseSource{APIBase: base.APIBase, Mirrors: base.Mirrors, TokenEnv: base.TokenEnv}
+	if own.APIBase != "" {
+		out.APIBase, out.TokenEnv = own.APIBase, own.TokenEnv
+	}
+	if own.TokenEnv != "" {
+		out.TokenEnv = own.TokenEnv
+	}
+	if len(own.Mirrors) > 0 {
+		out.Mirrors = own.Mirrors
+	}
+	if len(base.Rewrites)+len(own.Rewrites) > 0 {
+		out.Rewrites = map[string]string{}
+		for k, v := range base.Rewrites {
+			out.Rewrites[k] = v
+		}
+		for k, v := range own.Rewrites {
+			out.Rewrites[k] = v
+		}
+	}
+	return out
+}
+
+// ToolSigningKeys returns the keys accepted for tool's signatures: the "*"
+// entry of SigningKeys followed by the tool's own.
+func (p Profile) ToolSigningKeys(tool string) []string {
+	keys := append([]string{}, p.SigningKeys["*"]...)
+	if tool != "*" {
+		keys = append(keys, p.SigningKeys[tool]...)
+	}
+	return keys
+}
@@ -0,0 +1,229 @@
+// Package receipt records what nvimwiz placed on disk: one receipt per
+// installed tool, language server, Neovim version and launcher, kept under
+// the nvimwiz state dir.
+package receipt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/env"
+)
+
+type Kind string
+
+const (
+	Tool     Kind = "tool"
+	Server   Kind = "server"
+	Neovim   Kind = "neovim"
+	Launcher Kind = "launcher"
+)
+
+var kinds = []Kind{Tool, Server, Neovim, Launcher}
+
+type Receipt struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name"`
+	// Version is the installed release, e.g. "0.10.0"; empty when the
+	// installer cannot tell, as with go install and npm.
+	Version string `json:"version,omitempty"`
+	// Source is where the files came from: the asset URL, or e.g.
+	// "go install <pkg>@latest" for servers built locally. It is empty for
+	// files nvimwiz found already in place.
+	Source string `json:"source,omitempty"`
+	// SHA256 is the digest of the downloaded asset, or of the file written
+	// for launchers.
+	SHA256 string `json:"sha256,omitempty"`
+	// Paths lists every file, directory and symlink the install created.
+	Paths       []string `json:"paths"`
+	InstalledAt string   `json:"installedAt"`
+}
+
+// Dir is where receipts are kept: <state dir>/receipts.
+func Dir() (string, error) {
+	root, err := env.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "receipts"), nil
+}
+
+// Path returns the receipt file for kind and name.
+func Path(kind Kind, name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, string(kind), fileName(name)), nil
+}
+
+func fileName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name + ".json"
+}
+
+// Write saves r, replacing an earlier receipt for the same kind and name.
+// InstalledAt is set to now when empty.
+func Write(r Receipt) error {
+	if r.Kind == "" || strings.TrimSpace(r.Name) == "" {
+		return errors.New("receipt needs a kind and a name")
+	}
+	path, err := Path(r.Kind, r.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if r.InstalledAt == "" {
+		r.InstalledAt = time.Now().Format(time.RFC3339)
+	}
+	if r.Paths == nil {
+		r.Paths = []string{}
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the receipt for kind and name; false means there is none.
+func Load(kind Kind, name string) (Receipt, bool, error) {
+	path, err := Path(kind, name)
+	if err != nil {
+		return Receipt{}, false, err
+	}
+	return read(path)
+}
+
+func read(path string) (Receipt, bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Receipt{}, false, nil
+		}
+		return Receipt{}, false, err
+	}
+	var r Receipt
+	if err := json.Unmarshal(b, &r); err != nil {
+		return Receipt{}, false, err
+	}
+	return r, true, nil
+}
+
+// List returns every receipt, ordered by kind and name. Unreadable files
+// are skipped.
+func List() ([]Receipt, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	out := []Receipt{}
+	for _, k := range kinds {
+		entries, err := os.ReadDir(filepath.Join(dir, string(k)))
+		if err != nil {
+			continue
+		}
+		names := []string{}
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			if r, ok, err := read(filepath.Join(dir, string(k), n)); err == nil && ok {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Remove deletes the receipt for kind and name, not the files it lists.
+func Remove(kind Kind, name string) error {
+	path, err := Path(kind, name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Owns reports whether path is one of the paths r recorded, or lies
+// inside a recorded directory.
+func (r Receipt) Owns(path string) bool {
+	path = filepath.Clean(path)
+	for _, p := range r.Paths {
+		p = filepath.Clean(p)
+		if path == p || strings.HasPrefix(path, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the recorded paths that are no longer on disk.
+func (r Receipt) Missing() []string {
+	out := []string{}
+	for _, p := range r.Paths {
+		if _, err := os.Lstat(p); err != nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Time parses InstalledAt; the zero time means it is missing or invalid.
+func (r Receipt) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339, r.InstalledAt)
+	return t
+}
+
+// FileSHA256 returns the hex sha256 of the file at path.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RecordLauncher writes the receipt for the launcher script at path that
+// runs nvimBin, or nvim from PATH when nvimBin is empty.
+func RecordLauncher(appName, path, nvimBin string) error {
+	sum, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	src := strings.TrimSpace(nvimBin)
+	if src == "" {
+		src = "nvim on PATH"
+	}
+	return Write(Receipt{Kind: Launcher, Name: appName, Source: src, SHA256: sum, Paths: []string{path}})
+}
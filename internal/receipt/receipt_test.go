@@ -0,0 +1,116 @@
+package receipt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteLoadList(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if _, ok, err := Load(Tool, "rg"); ok || err != nil {
+		t.Fatalf("Load before Write = %v, %v", ok, err)
+	}
+	in := []Receipt{
+		{Kind: Tool, Name: "rg", Version: "14.1.1", Source: "https://example.com/rg.tar.gz", Paths: []string{"/a/rg"}},
+		{Kind: Tool, Name: "fd", Version: "10.2.0"},
+		{Kind: Neovim, Name: "v0.10.2", Version: "0.10.2"},
+	}
+	for _, r := range in {
+		if err := Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, ok, err := Load(Tool, "rg")
+	if !ok || err != nil {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	if got.Version != "14.1.1" || got.Source != in[0].Source || got.Time().IsZero() {
+		t.Fatalf("Load = %+v", got)
+	}
+
+	list, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, r := range list {
+		names = append(names, string(r.Kind)+"/"+r.Name)
+	}
+	if want := []string{"tool/fd", "tool/rg", "neovim/v0.10.2"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("List = %v, want %v", names, want)
+	}
+
+	if err := Remove(Tool, "rg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(Tool, "rg"); err != nil {
+		t.Fatalf("second Remove: %v", err)
+	}
+	if _, ok, _ := Load(Tool, "rg"); ok {
+		t.Fatal("receipt still there after Remove")
+	}
+	if err := Write(Receipt{Kind: Tool}); err == nil {
+		t.Fatal("Write without a name succeeded")
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path, err := Path(Tool, "rg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load(Tool, "rg"); err == nil {
+		t.Fatal("Load of a corrupt receipt succeeded")
+	}
+	if list, err := List(); err != nil || len(list) != 0 {
+		t.Fatalf("List = %v, %v; want the corrupt receipt skipped", list, err)
+	}
+}
+
+func TestPathSanitizesName(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir, _ := Dir()
+	for _, name := range []string{"../../x", "..", "a/b"} {
+		p, err := Path(Tool, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(p) != filepath.Join(dir, "tool") {
+			t.Errorf("Path(%q) = %s, outside the tool dir", name, p)
+		}
+	}
+}
+
+func TestOwnsAndMissing(t *testing.T) {
+	dir := t.TempDir()
+	have := filepath.Join(dir, "have")
+	if err := os.MkdirAll(have, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gone := filepath.Join(dir, "gone")
+	r := Receipt{Paths: []string{have, gone}}
+
+	for path, want := range map[string]bool{
+		have:                              true,
+		filepath.Join(have, "bin/x"):      true,
+		have + "-other":                   false,
+		dir:                               false,
+		filepath.Join(gone, "..", "gone"): true,
+	} {
+		if got := r.Owns(path); got != want {
+			t.Errorf("Owns(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if got := r.Missing(); !reflect.DeepEqual(got, []string{gone}) {
+		t.Fatalf("Missing = %v, want [%s]", got, gone)
+	}
+}
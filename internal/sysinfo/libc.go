@@ -0,0 +1,133 @@
+package sysinfo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Libc describes the C library of a Linux host.
+type Libc struct {
+	// Flavor is "glibc", "musl", or "" when unknown or not on Linux.
+	Flavor string
+	// Version is e.g. "2.35" for glibc or "1.2.4" for musl; it may be
+	// empty.
+	Version string
+}
+
+func (l Libc) String() string {
+	if l.Flavor == "" {
+		return "unknown libc"
+	}
+	if l.Version == "" {
+		return l.Flavor
+	}
+	return l.Flavor + " " + l.Version
+}
+
+// AtLeast reports whether l's version is min or newer. An unknown version
+// counts as new enough.
+func (l Libc) AtLeast(min string) bool {
+	if l.Version == "" || min == "" {
+		return true
+	}
+	have, want := versionParts(l.Version), versionParts(min)
+	for i := 0; i < len(want); i++ {
+		h := 0
+		if i < len(have) {
+			h = have[i]
+		}
+		if h != want[i] {
+			return h > want[i]
+		}
+	}
+	return true
+}
+
+func versionParts(v string) []int {
+	out := []int{}
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+var (
+	libcVersionPattern = regexp.MustCompile(`([0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+	muslVersionPattern = regexp.MustCompile(`(?m)^Version ([0-9.]+)`)
+)
+
+// ParseLibc reads a libc description such as "musl", "glibc 2.28" or
+// "glibc-2.28".
+func ParseLibc(s string) Libc {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var l Libc
+	switch {
+	case strings.HasPrefix(s, "musl"):
+		l.Flavor = "musl"
+	case strings.HasPrefix(s, "glibc"), strings.HasPrefix(s, "gnu"):
+		l.Flavor = "glibc"
+	default:
+		return Libc{}
+	}
+	l.Version = libcVersionPattern.FindString(s)
+	return l
+}
+
+// libcCommand runs a command DetectLibc probes the system with and returns
+// its combined output; tests replace it.
+var libcCommand = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// muslLoaders lists the musl dynamic loaders installed; tests replace it.
+var muslLoaders = func() []string {
+	loaders, _ := filepath.Glob("/lib/ld-musl-*.so.1")
+	return loaders
+}
+
+// DetectLibc reports the C library of this host. NVIMWIZ_LIBC overrides
+// detection, e.g. "musl" or "glibc 2.28".
+func DetectLibc() Libc {
+	if v := os.Getenv("NVIMWIZ_LIBC"); strings.TrimSpace(v) != "" {
+		return ParseLibc(v)
+	}
+	if runtime.GOOS != "linux" {
+		return Libc{}
+	}
+	if out, err := libcCommand("getconf", "GNU_LIBC_VERSION"); err == nil {
+		if l := ParseLibc(string(out)); l.Flavor != "" {
+			return l
+		}
+	}
+	if loaders := muslLoaders(); len(loaders) > 0 {
+		// The musl loader prints its version when run without arguments.
+		out, _ := libcCommand(loaders[0])
+		l := Libc{Flavor: "musl"}
+		if m := muslVersionPattern.FindStringSubmatch(string(out)); m != nil {
+			l.Version = m[1]
+		}
+		return l
+	}
+	out, _ := libcCommand("ldd", "--version")
+	low := strings.ToLower(string(out))
+	switch {
+	case strings.Contains(low, "musl"):
+		l := Libc{Flavor: "musl"}
+		if m := muslVersionPattern.FindStringSubmatch(string(out)); m != nil {
+			l.Version = m[1]
+		}
+		return l
+	case strings.Contains(low, "glibc"), strings.Contains(low, "gnu libc"):
+		return Libc{Flavor: "glibc", Version: libcVersionPattern.FindString(firstLine(out))}
+	}
+	return Libc{}
+}
@@ -0,0 +1,144 @@
+package sysinfo
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestParseLibc(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Libc
+	}{
+		{"musl", Libc{Flavor: "musl"}},
+		{"musl 1.2.4", Libc{Flavor: "musl", Version: "1.2.4"}},
+		{"glibc 2.35\n", Libc{Flavor: "glibc", Version: "2.35"}},
+		{"glibc-2.28", Libc{Flavor: "glibc", Version: "2.28"}},
+		{" GLIBC 2.17 ", Libc{Flavor: "glibc", Version: "2.17"}},
+		{"gnu", Libc{Flavor: "glibc"}},
+		{"bionic", Libc{}},
+		{"", Libc{}},
+	}
+	for _, tt := range tests {
+		if got := ParseLibc(tt.in); got != tt.want {
+			t.Errorf("ParseLibc(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLibcAtLeast(t *testing.T) {
+	tests := []struct {
+		have, min string
+		want      bool
+	}{
+		{"2.35", "2.31", true},
+		{"2.31", "2.31", true},
+		{"2.28", "2.31", false},
+		{"2.9", "2.17", false},
+		{"3.0", "2.31", true},
+		{"2", "2.1", false},
+		{"", "2.31", true},
+		{"2.28", "", true},
+	}
+	for _, tt := range tests {
+		if got := (Libc{Flavor: "glibc", Version: tt.have}).AtLeast(tt.min); got != tt.want {
+			t.Errorf("%q.AtLeast(%q) = %v, want %v", tt.have, tt.min, got, tt.want)
+		}
+	}
+}
+
+type cannedOutput struct {
+	out string
+	err error
+}
+
+func TestDetectLibc(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("libc detection runs on Linux only")
+	}
+	failed := cannedOutput{err: errors.New("exit status 1")}
+	const loader = "/lib/ld-musl-x86_64.so.1"
+	tests := []struct {
+		name    string
+		env     string
+		loaders []string
+		outputs map[string]cannedOutput
+		want    Libc
+	}{
+		{
+			name:    "getconf",
+			outputs: map[string]cannedOutput{"getconf": {out: "glibc 2.35\n"}},
+			want:    Libc{Flavor: "glibc", Version: "2.35"},
+		},
+		{
+			name:    "musl loader",
+			loaders: []string{loader},
+			outputs: map[string]cannedOutput{
+				"getconf": failed,
+				loader:    {out: "musl libc (x86_64)\nVersion 1.2.4\nDynamic Program Loader\n", err: errors.New("exit status 1")},
+			},
+			want: Libc{Flavor: "musl", Version: "1.2.4"},
+		},
+		{
+			name: "ldd musl",
+			outputs: map[string]cannedOutput{
+				"getconf": failed,
+				"ldd":     {out: "musl libc (aarch64)\nVersion 1.2.3\nDynamic Program Loader\nUsage: ldd [options] [--] pathname\n", err: errors.New("exit status 1")},
+			},
+			want: Libc{Flavor: "musl", Version: "1.2.3"},
+		},
+		{
+			name: "ldd glibc",
+			outputs: map[string]cannedOutput{
+				"getconf": failed,
+				"ldd":     {out: "ldd (Ubuntu GLIBC 2.35-0ubuntu3.6) 2.35\nCopyright (C) 2022 Free Software Foundation, Inc.\n"},
+			},
+			want: Libc{Flavor: "glibc", Version: "2.35"},
+		},
+		{
+			name: "ldd gnu libc",
+			outputs: map[string]cannedOutput{
+				"getconf": failed,
+				"ldd":     {out: "ldd (GNU libc) 2.28\n"},
+			},
+			want: Libc{Flavor: "glibc", Version: "2.28"},
+		},
+		{
+			name:    "nothing answers",
+			outputs: map[string]cannedOutput{"getconf": failed, "ldd": failed},
+			want:    Libc{},
+		},
+		{
+			name:    "override",
+			env:     "glibc 2.28",
+			outputs: map[string]cannedOutput{"getconf": {out: "glibc 2.35\n"}},
+			want:    Libc{Flavor: "glibc", Version: "2.28"},
+		},
+		{
+			name:    "override musl",
+			env:     "musl",
+			outputs: map[string]cannedOutput{"getconf": {out: "glibc 2.35\n"}},
+			want:    Libc{Flavor: "musl"},
+		},
+	}
+	defer func(c func(string, ...string) ([]byte, error), l func() []string) {
+		libcCommand, muslLoaders = c, l
+	}(libcCommand, muslLoaders)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NVIMWIZ_LIBC", tt.env)
+			muslLoaders = func() []string { return tt.loaders }
+			libcCommand = func(name string, args ...string) ([]byte, error) {
+				o, ok := tt.outputs[name]
+				if !ok {
+					return nil, errors.New(name + ": not found")
+				}
+				return []byte(o.out), o.err
+			}
+			if got := DetectLibc(); got != tt.want {
+				t.Errorf("DetectLibc() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ type Info struct {
 	ID              string
 	VersionID       string
 	WSL             bool
+	Libc            Libc
 	PackageManagers []string
 	Tools           map[string]ToolInfo
 }
@@ -35,15 +36,16 @@ func Collect() Info {
 		Tools:  map[string]ToolInfo{},
 	}
 	readOSRelease(&info)
+	info.Libc = DetectLibc()
 	info.PackageManagers = detectPackageManagers()
-	for _, k := range []string{"git", "curl", "tar", "unzip", "sha256sum", "nvim", "rg", "fd", "node", "python3", "go", "java"} {
+	for _, k := range []string{"git", "curl", "tar", "unzip", "sha256sum", "nvim", "rg", "fd", "node", "npm", "python3", "go", "java"} {
 		info.Tools[k] = detectTool(k)
 	}
 	return info
 }
 
 func detectPackageManagers() []string {
-	candidates := []string{"dnf", "apt", "pacman", "zypper", "brew"}
+	candidates := []string{"dnf", "apt", "pacman", "zypper", "apk", "brew"}
 	out := []string{}
 	for _, c := range candidates {
 		if _, err := exec.LookPath(c); err == nil {
@@ -85,6 +87,8 @@ func versionCmd(name string) []string {
 		return []string{"python3", "--version"}
 	case "node":
 		return []string{"node", "--version"}
+	case "npm":
+		return []string{"npm", "--version"}
 	case "java":
 		return []string{"java", "-version"}
 	default:
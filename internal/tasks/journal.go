@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/profile"
+)
+
+// Journal records the progress of an apply run on disk so that a run
+// interrupted by a crash or a closed terminal can be resumed later.
+type Journal struct {
+	mu sync.Mutex
+
+	Profile   string   `json:"profile"`
+	PlanHash  string   `json:"planHash"`
+	Steps     []string `json:"steps"`
+	Completed []string `json:"completed"`
+	State     State    `json:"state"`
+	FailedAt  int      `json:"failedAt"`
+	Error     string   `json:"error,omitempty"`
+	Finished  bool     `json:"finished"`
+	StartedAt string   `json:"startedAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+func NewJournal(p profile.Profile, plan []Task) *Journal {
+	steps := make([]string, 0, len(plan))
+	for _, t := range plan {
+		steps = append(steps, t.ID)
+	}
+	return &Journal{
+		Profile:   p.Name,
+		PlanHash:  PlanHash(p, plan),
+		Steps:     steps,
+		Completed: []string{},
+		FailedAt:  -1,
+		StartedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// PlanHash identifies a profile and the plan built from it. A journal can
+// only be resumed while the hash still matches.
+func PlanHash(p profile.Profile, plan []Task) string {
+	h := sha256.New()
+	b, _ := json.Marshal(p)
+	h.Write(b)
+	for _, t := range plan {
+		h.Write([]byte{0})
+		h.Write([]byte(t.ID))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func JournalPath() (string, error) {
+	root, err := profile.BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "last-run.json"), nil
+}
+
+// LoadJournal reads the last run's journal; false means there is none. A
+// journal that cannot be parsed is an error rather than no journal, so it
+// is reported instead of silently starting over.
+func LoadJournal() (*Journal, bool, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	var j Journal
+	if err := json.Unmarshal(b, &j); err != nil {
+		return nil, false, fmt.Errorf("the last run's journal %s is corrupt: %w", path, err)
+	}
+	return &j, true, nil
+}
+
+// ResumeIndex returns where an unfinished run of plan should continue, or
+// false when the journal does not belong to p and plan or has nothing left.
+func (j *Journal) ResumeIndex(p profile.Profile, plan []Task) (int, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Finished || j.Profile != p.Name || j.PlanHash != PlanHash(p, plan) {
+		return 0, false
+	}
+	done := map[string]bool{}
+	for _, id := range j.Completed {
+		done[id] = true
+	}
+	for i, t := range plan {
+		if !done[t.ID] {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ResumeState returns a copy of the resolved paths recorded so far.
+func (j *Journal) ResumeState() *State {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := &State{}
+	st.copyFrom(&j.State)
+	return st
+}
+
+// Track records finished tasks from the event stream and saves the
+// journal after each one, then forwards every event to emit.
+func (j *Journal) Track(st *State, emit events.Sink) events.Sink {
+	return func(e events.Event) {
+		if e.Kind == events.TaskStarted || e.Kind == events.TaskFinished {
+			j.mu.Lock()
+			if e.Kind == events.TaskFinished && e.Step >= 1 && e.Step <= len(j.Steps) {
+				j.Completed = appendUnique(j.Completed, j.Steps[e.Step-1])
+			}
+			j.snapshot(st)
+			_ = j.save()
+			j.mu.Unlock()
+		}
+		emit.Emit(e)
+	}
+}
+
+// Finish records the outcome of RunFrom.
+func (j *Journal) Finish(st *State, failedAt int, err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshot(st)
+	j.FailedAt = failedAt
+	j.Error = ""
+	if err != nil {
+		j.Error = err.Error()
+	}
+	j.Finished = err == nil
+	return j.save()
+}
+
+func (j *Journal) snapshot(st *State) {
+	if st == nil {
+		return
+	}
+	j.State.copyFrom(st)
+}
+
+func (j *Journal) save() error {
+	path, err := JournalPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	j.UpdatedAt = time.Now().Format(time.RFC3339)
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, x := range list {
+		if x == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
@@ -0,0 +1,111 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/profile"
+)
+
+func journalPlan(fail string) []Task {
+	plan := []Task{}
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		plan = append(plan, Task{ID: id, Name: id, Run: func(ctx context.Context, st *State, emit events.Sink) error {
+			if id == fail {
+				return errors.New(id + " failed")
+			}
+			st.update(func(st *State) { st.setPath(id, "/bin/"+id) })
+			return nil
+		}})
+	}
+	return plan
+}
+
+func TestJournalResume(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	p := profile.Profile{Name: "work", Features: map[string]bool{"x": true}}
+	plan := journalPlan("b")
+
+	j := NewJournal(p, plan)
+	st := &State{}
+	st, failedAt, err := RunFrom(context.Background(), plan, st, 0, 1, j.Track(st, nil))
+	if err == nil || failedAt != 1 {
+		t.Fatalf("RunFrom = %d, %v; want a failure at 1", failedAt, err)
+	}
+	if err := j.Finish(st, failedAt, err); err != nil {
+		t.Fatal(err)
+	}
+
+	last, ok, err := LoadJournal()
+	if err != nil || !ok {
+		t.Fatalf("LoadJournal = %v, %v", ok, err)
+	}
+	if last.Finished || last.Error != "b failed" {
+		t.Fatalf("journal = %+v", last)
+	}
+	if i, ok := last.ResumeIndex(p, plan); !ok || i != 1 {
+		t.Fatalf("ResumeIndex = %d, %v; want 1", i, ok)
+	}
+	if got := last.ResumeState().Paths["a"]; got != "/bin/a" {
+		t.Fatalf("resumed state lost a's path: %q", got)
+	}
+
+	changed := p
+	changed.Features = map[string]bool{"x": false}
+	if _, ok := last.ResumeIndex(changed, plan); ok {
+		t.Fatal("resumable after the profile changed")
+	}
+	other := p
+	other.Name = "home"
+	if _, ok := last.ResumeIndex(other, plan); ok {
+		t.Fatal("resumable for another profile")
+	}
+	if _, ok := last.ResumeIndex(p, plan[:2]); ok {
+		t.Fatal("resumable with a different plan")
+	}
+
+	// Finishing the run leaves nothing to resume.
+	plan = journalPlan("")
+	st = last.ResumeState()
+	st, failedAt, err = RunFrom(context.Background(), plan, st, 1, 1, last.Track(st, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := last.Finish(st, failedAt, err); err != nil {
+		t.Fatal(err)
+	}
+	last, _, _ = LoadJournal()
+	if _, ok := last.ResumeIndex(p, plan); ok || !last.Finished {
+		t.Fatalf("finished run still resumable: %+v", last)
+	}
+}
+
+func TestLoadJournalMissingAndCorrupt(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if j, ok, err := LoadJournal(); j != nil || ok || err != nil {
+		t.Fatalf("no journal: %v, %v, %v", j, ok, err)
+	}
+	path, err := JournalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"profile": "work", "steps": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	j, ok, err := LoadJournal()
+	if err == nil || ok || j != nil {
+		t.Fatalf("corrupt journal: %v, %v, %v", j, ok, err)
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Fatalf("error %q does not name %s", err, path)
+	}
+}
@@ -0,0 +1,171 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/profile"
+)
+
+// RetryPolicy controls how often the runner retries a failing task.
+type RetryPolicy struct {
+	// Attempts is the total number of tries; values below 1 mean one try.
+	Attempts int
+	// Backoff is the wait before the second attempt; it doubles after that.
+	Backoff time.Duration
+	// Retryable reports whether an error is worth another attempt. Nil
+	// means DefaultRetryable.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryOn is the error classes DefaultRetryable retries.
+var DefaultRetryOn = []string{"timeout", "network", "5xx", "429"}
+
+// DefaultRetryable retries timeouts and transient network or server errors.
+var DefaultRetryable = mustRetryOn(DefaultRetryOn)
+
+// RetryOn returns a Retryable that retries errors in any of classes:
+// "timeout" (an attempt ran out of time), "network" (connection failures
+// and downloads that broke off), "5xx" (any server error status), an HTTP
+// status code such as "429" or "403", or "any" for every error.
+// Cancellation is never retried.
+func RetryOn(classes []string) (func(err error) bool, error) {
+	checks := []func(err error) bool{}
+	for _, c := range classes {
+		check, err := retryClass(c)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return func(err error) bool {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return false
+		}
+		for _, ok := range checks {
+			if ok(err) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func retryClass(c string) (func(err error) bool, error) {
+	c = strings.ToLower(strings.TrimSpace(c))
+	switch c {
+	case "timeout":
+		return func(err error) bool { return errors.Is(err, context.DeadlineExceeded) }, nil
+	case "network":
+		return install.IsNetworkError, nil
+	case "5xx":
+		return func(err error) bool { return install.HTTPStatus(err) >= 500 }, nil
+	case "any":
+		return func(err error) bool { return true }, nil
+	}
+	code, err := strconv.Atoi(c)
+	if err != nil || code < 100 || code > 599 {
+		return nil, fmt.Errorf("unknown retry class %q (want timeout, network, 5xx, any or an HTTP status)", c)
+	}
+	return func(err error) bool { return install.HTTPStatus(err) == code }, nil
+}
+
+// knownRetryClasses splits classes into those RetryOn accepts and the rest.
+func knownRetryClasses(classes []string) (known, unknown []string) {
+	for _, c := range classes {
+		if _, err := retryClass(c); err != nil {
+			unknown = append(unknown, c)
+		} else {
+			known = append(known, c)
+		}
+	}
+	return known, unknown
+}
+
+func mustRetryOn(classes []string) func(err error) bool {
+	fn, err := RetryOn(classes)
+	if err != nil {
+		panic(err)
+	}
+	return fn
+}
+
+// retryAnyError retries everything except cancellation. It suits tasks such
+// as plugin sync whose failures are mostly network related but untyped.
+func retryAnyError(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// ApplyPolicy overrides the timeout and retry settings of every task in plan
+// with the non-empty fields of pol.
+func ApplyPolicy(plan []Task, pol profile.TaskPolicy) {
+	for i := range plan {
+		applyPolicy(&plan[i], pol)
+	}
+}
+
+func applyProfilePolicies(plan []Task, p profile.Profile) {
+	for i := range plan {
+		if p.TaskPolicy != nil {
+			applyPolicy(&plan[i], *p.TaskPolicy)
+		}
+		if pol, ok := p.TaskPolicies[plan[i].ID]; ok {
+			applyPolicy(&plan[i], pol)
+		}
+	}
+}
+
+func applyPolicy(t *Task, pol profile.TaskPolicy) {
+	if d, err := time.ParseDuration(pol.Timeout); err == nil && d >= 0 {
+		t.Timeout = d
+	}
+	if pol.Attempts > 0 {
+		t.Retry.Attempts = pol.Attempts
+	}
+	if d, err := time.ParseDuration(pol.Backoff); err == nil && d >= 0 {
+		t.Retry.Backoff = d
+	}
+	if known, _ := knownRetryClasses(pol.RetryOn); len(known) > 0 {
+		t.Retry.Retryable = mustRetryOn(known)
+	}
+}
+
+// PolicyWarnings describes the fields of p's task policies that the runner
+// ignores because it cannot use them.
+func PolicyWarnings(p profile.Profile) []string {
+	out := []string{}
+	if p.TaskPolicy != nil {
+		out = policyWarnings("taskPolicy", *p.TaskPolicy)
+	}
+	ids := make([]string, 0, len(p.TaskPolicies))
+	for id := range p.TaskPolicies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		out = append(out, policyWarnings("taskPolicies."+id, p.TaskPolicies[id])...)
+	}
+	return out
+}
+
+func policyWarnings(where string, pol profile.TaskPolicy) []string {
+	out := []string{}
+	for _, f := range []struct{ name, value string }{{"timeout", pol.Timeout}, {"backoff", pol.Backoff}} {
+		if f.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(f.value); err != nil || d < 0 {
+			out = append(out, fmt.Sprintf("%s: %s %q is not a duration such as 10m or 5s; ignored", where, f.name, f.value))
+		}
+	}
+	if _, unknown := knownRetryClasses(pol.RetryOn); len(unknown) > 0 {
+		out = append(out, fmt.Sprintf("%s: unknown retry classes %q ignored (want timeout, network, 5xx, any or an HTTP status)", where, unknown))
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/profile"
+)
+
+// httpFailure returns the error an install gets when the release API
+// answers with code. Codes the installer retries itself are slow here.
+func httpFailure(t *testing.T, code int) error {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", code)
+	}))
+	defer srv.Close()
+	_, err := install.Install(context.Background(), "ripgrep", install.Options{Source: install.Source{APIBase: srv.URL}}, nil)
+	if install.HTTPStatus(err) != code {
+		t.Fatalf("install against a %d server: %v", code, err)
+	}
+	return err
+}
+
+func TestRetryOn(t *testing.T) {
+	netErr := fmt.Errorf("download: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	timeout := fmt.Errorf("attempt: %w", context.DeadlineExceeded)
+	cut := fmt.Errorf("read body: %w", io.ErrUnexpectedEOF)
+	plain := errors.New("checksum mismatch")
+	e404, e403 := httpFailure(t, 404), httpFailure(t, 403)
+
+	tests := []struct {
+		classes []string
+		retry   []error
+		noRetry []error
+	}{
+		{DefaultRetryOn, []error{netErr, timeout, cut}, []error{plain, e403, e404, context.Canceled}},
+		{[]string{"network"}, []error{netErr, cut}, []error{timeout, e403, plain}},
+		{[]string{"5xx"}, nil, []error{e403, e404, netErr}},
+		{[]string{" 403 "}, []error{e403}, []error{e404, netErr}},
+		{[]string{"404", "403"}, []error{e403, e404}, []error{plain}},
+		{[]string{"timeout"}, []error{timeout}, []error{netErr, e403}},
+		{[]string{"ANY"}, []error{plain, e403, netErr}, []error{context.Canceled, fmt.Errorf("x: %w", context.Canceled), nil}},
+		{nil, nil, []error{plain, netErr, timeout}},
+	}
+	for _, tt := range tests {
+		fn, err := RetryOn(tt.classes)
+		if err != nil {
+			t.Fatalf("RetryOn(%q): %v", tt.classes, err)
+		}
+		for _, e := range tt.retry {
+			if !fn(e) {
+				t.Errorf("RetryOn(%q) does not retry %v", tt.classes, e)
+			}
+		}
+		for _, e := range tt.noRetry {
+			if fn(e) {
+				t.Errorf("RetryOn(%q) retries %v", tt.classes, e)
+			}
+		}
+	}
+
+	for _, bad := range [][]string{{"flaky"}, {"99"}, {"600"}} {
+		if _, err := RetryOn(bad); err == nil {
+			t.Errorf("RetryOn(%q) accepted", bad)
+		}
+	}
+}
+
+func TestApplyPolicyRetryOn(t *testing.T) {
+	plan := []Task{{ID: "a", Retry: installPolicy}, {ID: "b", Retry: syncPolicy}}
+	ApplyPolicy(plan, profilePolicy("403"))
+	e403 := httpFailure(t, 403)
+	for _, task := range plan {
+		if !task.Retry.Retryable(e403) || task.Retry.Retryable(errors.New("other")) {
+			t.Errorf("%s: retryOn [403] not applied", task.ID)
+		}
+	}
+
+	plan = []Task{{ID: "b", Retry: syncPolicy}}
+	ApplyPolicy(plan, profilePolicy("bogus"))
+	if !plan[0].Retry.Retryable(errors.New("other")) {
+		t.Error("an unknown class replaced the task's own policy")
+	}
+
+	plan = []Task{{ID: "b", Retry: syncPolicy}}
+	ApplyPolicy(plan, profilePolicy("403", "bogus"))
+	if !plan[0].Retry.Retryable(e403) || plan[0].Retry.Retryable(errors.New("other")) {
+		t.Error("retryOn [403 bogus] did not keep 403 alone")
+	}
+}
+
+func TestPolicyWarnings(t *testing.T) {
+	p := profile.Profile{
+		TaskPolicy: &profile.TaskPolicy{Timeout: "10m", Backoff: "soon", RetryOn: []string{"403", "bogus"}},
+		TaskPolicies: map[string]profile.TaskPolicy{
+			"plugins.sync": {Timeout: "-1s"},
+			"install.fd":   {RetryOn: []string{"5xx"}},
+		},
+	}
+	got := PolicyWarnings(p)
+	want := []string{
+		`taskPolicy: backoff "soon" is not a duration such as 10m or 5s; ignored`,
+		`taskPolicy: unknown retry classes ["bogus"] ignored (want timeout, network, 5xx, any or an HTTP status)`,
+		`taskPolicies.plugins.sync: timeout "-1s" is not a duration such as 10m or 5s; ignored`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("PolicyWarnings = %q, want %q", got, want)
+	}
+	if got := PolicyWarnings(profile.Profile{}); len(got) != 0 {
+		t.Errorf("PolicyWarnings(empty) = %q", got)
+	}
+}
+
+func profilePolicy(classes ...string) profile.TaskPolicy {
+	return profile.TaskPolicy{RetryOn: classes}
+}
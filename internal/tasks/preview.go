@@ -0,0 +1,189 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/nvimcfg"
+	"nvimwiz/internal/profile"
+)
+
+type TaskPreview struct {
+	Name  string
+	Lines []string
+	Err   error
+}
+
+// Preview describes every task in plan without running it. Installer
+// previews query GitHub for the release they would fetch.
+func Preview(ctx context.Context, plan []Task) []TaskPreview {
+	out := make([]TaskPreview, 0, len(plan))
+	for _, t := range plan {
+		pv := TaskPreview{Name: t.Name}
+		if t.Preview != nil {
+			pv.Lines, pv.Err = t.Preview(ctx)
+		}
+		out = append(out, pv)
+	}
+	return out
+}
+
+func previewInstall(ctx context.Context, featureID string, opts install.Options) ([]string, error) {
+	pv, ok := install.PreviewFeature(ctx, featureID, opts)
+	if !ok {
+		return nil, errors.New("unknown install feature " + featureID)
+	}
+	st := pv.Status
+
+	lines := []string{}
+	if st.TargetOK {
+		rel := "Release: " + pv.Repo + " " + st.TargetTag
+		if st.TargetTag != "v"+st.TargetVersion && st.TargetTag != st.TargetVersion {
+			rel += " (" + st.TargetVersion + ")"
+		}
+		if st.Pinned != "" {
+			rel += ", pinned to " + st.Pinned
+		}
+		lines = append(lines, rel)
+	} else {
+		msg := "Release: " + pv.Repo + " (version unknown)"
+		if st.Pinned != "" {
+			msg = "Release: " + pv.Repo + " " + st.Pinned + " (pinned, not resolved)"
+		}
+		if strings.TrimSpace(st.Error) != "" {
+			msg += ": " + strings.TrimSpace(st.Error)
+		}
+		lines = append(lines, msg)
+	}
+	if st.Asset != "" {
+		asset := "Asset: " + st.Asset
+		if st.AssetNote != "" {
+			asset += " (" + st.AssetNote + ")"
+		}
+		lines = append(lines, asset)
+	} else if st.AssetNote != "" {
+		lines = append(lines, "Asset: none, "+st.AssetNote)
+	}
+	if st.Present {
+		cur := "unknown version"
+		if st.CurrentOK && st.CurrentVersion != "" {
+			cur = st.CurrentVersion
+		}
+		lines = append(lines, "Installed: "+cur+" at "+st.Path)
+	} else {
+		lines = append(lines, "Installed: not found")
+	}
+	if pv.Skip {
+		lines = append(lines, "Action: skip download, already up to date")
+		return lines, nil
+	}
+	lines = append(lines, "Action: download, verify and install")
+	for _, f := range pv.Files {
+		lines = append(lines, "  write "+f)
+	}
+	return lines, nil
+}
+
+func previewBoundNvim(ctx context.Context, version, appName string) ([]string, error) {
+	_ = ctx
+	lines := []string{}
+	if v, ok := install.InstalledNvimVersion(version); ok {
+		lines = append(lines, "Neovim "+v.Tag+": installed at "+v.Dir)
+	} else {
+		lines = append(lines, "Neovim "+version+": download, verify and unpack under ~/.local/nvim")
+	}
+	launcher, err := env.NvimAppLauncherPath(appName)
+	if err != nil {
+		return lines, err
+	}
+	lines = append(lines, "Launcher "+launcher+": rewritten to run this version if it exists")
+	lines = append(lines, "~/.local/bin/nvim is not changed")
+	return lines, nil
+}
+
+func previewServer(srv install.ServerSpec) ([]string, error) {
+	st := install.StatusForServer(srv)
+	if st.Installed {
+		return []string{"Found: " + st.Path, "Action: skip"}, nil
+	}
+	lines := []string{"Installed: not found"}
+	switch srv.Method {
+	case install.GoInstall:
+		lines = append(lines, "Action: go install "+srv.GoPackage+"@latest")
+	case install.Npm:
+		lines = append(lines, "Action: npm install "+strings.Join(srv.NpmPackages, " "))
+	default:
+		lines = append(lines, "Action: download the latest "+srv.Release.Owner+"/"+srv.Release.Repo+" release")
+	}
+	if dir, err := install.LSPBinDir(); err == nil {
+		lines = append(lines, "  into "+dir)
+	}
+	return lines, nil
+}
+
+func previewConfigWrite(p profile.Profile, cat catalog.Catalog) ([]string, error) {
+	wp, err := nvimcfg.PlanWrite(p, cat)
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{"Config dir: " + wp.Root}
+	counts := map[string]int{}
+	for _, f := range wp.Files {
+		counts[f.Action]++
+		if f.Action == nvimcfg.ChangeUnchanged {
+			continue
+		}
+		rel, err := filepath.Rel(wp.Root, f.Path)
+		if err != nil {
+			rel = f.Path
+		}
+		lines = append(lines, "  "+f.Action+" "+rel)
+	}
+	if counts[nvimcfg.ChangeUnchanged] > 0 {
+		lines = append(lines, "  ("+strconv.Itoa(counts[nvimcfg.ChangeUnchanged])+" files unchanged)")
+	}
+	if wp.InitBackup {
+		lines = append(lines, "Existing init.lua is not managed by nvimwiz and will be backed up to init.lua.bak-<timestamp>")
+	}
+	return lines, nil
+}
+
+func previewLazySync(p profile.Profile) ([]string, error) {
+	cfgDir, err := nvimcfg.ConfigDirForProfile(p)
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		"Runs: nvim --headless -u " + filepath.Join(cfgDir, "nvimwiz_headless_init.vim") + " +\"Lazy sync\" +qa",
+		"With: NVIM_APPNAME=" + p.EffectiveAppName(),
+	}, nil
+}
+
+// FormatPreview renders previews as numbered, indented lines shared by the
+// CLI and the Preview page.
+func FormatPreview(previews []TaskPreview) []string {
+	lines := []string{}
+	total := len(previews)
+	if total == 0 {
+		return []string{"Nothing to do: no tasks are enabled."}
+	}
+	for i, pv := range previews {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strconv.Itoa(i+1)+"/"+strconv.Itoa(total)+" "+pv.Name)
+		for _, l := range pv.Lines {
+			lines = append(lines, "    "+l)
+		}
+		if pv.Err != nil {
+			lines = append(lines, "    Preview error: "+pv.Err.Error())
+		}
+	}
+	return lines
+}
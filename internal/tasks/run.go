@@ -0,0 +1,255 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nvimwiz/internal/events"
+)
+
+// DefaultWorkers bounds how many independent tasks run at the same time.
+const DefaultWorkers = 3
+
+// RunFrom runs plan starting at index start and reports progress through
+// emit. Tasks before start count as already done. Independent tasks run
+// concurrently on up to workers goroutines; each task's log events are
+// delivered as one group, in plan order, while download progress is
+// forwarded as it happens.
+//
+// On failure RunFrom stops starting new tasks, waits for running ones and
+// returns the lowest index that did not complete, so retrying from that
+// index resumes the plan. When ctx is cancelled the returned error
+// satisfies errors.Is(err, context.Canceled).
+func RunFrom(ctx context.Context, plan []Task, st *State, start int, workers int, emit events.Sink) (*State, int, error) {
+	if st == nil {
+		st = &State{}
+	}
+	total := len(plan)
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	index := map[string]int{}
+	for i, t := range plan {
+		if t.ID != "" {
+			index[t.ID] = i
+		}
+	}
+
+	out := newOrderedSink(emit, start, total)
+
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result)
+	started := make([]bool, total)
+	done := make([]bool, total)
+	running := 0
+	var firstErr error
+	firstFailed := -1
+
+	ready := func(i int) bool {
+		for _, dep := range plan[i].Deps {
+			d, ok := index[dep]
+			if !ok || d == i || d < start {
+				continue
+			}
+			if !done[d] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		if firstErr == nil && ctx.Err() == nil {
+			for i := start; i < total && running < workers; i++ {
+				if started[i] || !ready(i) {
+					continue
+				}
+				started[i] = true
+				running++
+				t := plan[i]
+				temit := out.forTask(i, t.Name)
+				temit.Emit(events.Event{Kind: events.TaskStarted})
+				go func(i int, t Task, temit events.Sink) {
+					results <- result{i: i, err: runTask(ctx, t, st, temit)}
+				}(i, t, temit)
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		r := <-results
+		running--
+		temit := out.forTask(r.i, plan[r.i].Name)
+		switch {
+		case r.err == nil:
+			done[r.i] = true
+			temit.Emit(events.Event{Kind: events.TaskFinished})
+		case ctx.Err() != nil:
+			temit.Emit(events.Event{Kind: events.TaskCancelled, Error: ctx.Err().Error()})
+		default:
+			temit.Emit(events.Event{Kind: events.TaskFailed, Error: r.err.Error()})
+			if firstFailed < 0 || r.i < firstFailed {
+				firstFailed = r.i
+				firstErr = r.err
+			}
+		}
+		out.finish(r.i)
+	}
+
+	failedAt := -1
+	for i := start; i < total; i++ {
+		if !done[i] {
+			failedAt = i
+			break
+		}
+	}
+	if failedAt < 0 {
+		return st, -1, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		if !started[failedAt] {
+			out.forTask(failedAt, plan[failedAt].Name).Emit(events.Event{Kind: events.TaskCancelled, Error: err.Error()})
+		}
+		out.flushAll()
+		return st, failedAt, err
+	}
+	out.flushAll()
+	if firstErr == nil {
+		return st, failedAt, errors.New("dependency cycle at " + plan[failedAt].Name)
+	}
+	if failedAt != firstFailed {
+		return st, failedAt, fmt.Errorf("not run because %s failed: %w", plan[firstFailed].Name, firstErr)
+	}
+	return st, failedAt, firstErr
+}
+
+// orderedSink delivers each task's events as a contiguous group in plan
+// order. The earliest unfinished task streams live; later tasks buffer
+// until every task before them has finished.
+type orderedSink struct {
+	mu   sync.Mutex
+	emit events.Sink
+	head int
+	end  int
+	bufs map[int][]events.Event
+	fin  map[int]bool
+}
+
+func newOrderedSink(emit events.Sink, start, total int) *orderedSink {
+	return &orderedSink{
+		emit: emit,
+		head: start,
+		end:  total,
+		bufs: map[int][]events.Event{},
+		fin:  map[int]bool{},
+	}
+}
+
+func (o *orderedSink) forTask(i int, name string) events.Sink {
+	if o.emit == nil {
+		return nil
+	}
+	return events.Sink(func(e events.Event) {
+		if e.Kind == events.DownloadProgress {
+			o.emit(e)
+			return
+		}
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if i == o.head {
+			o.emit(e)
+			return
+		}
+		o.bufs[i] = append(o.bufs[i], e)
+	}).WithTask(name, i+1, o.end)
+}
+
+func (o *orderedSink) finish(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fin[i] = true
+	for o.head < o.end && o.fin[o.head] {
+		o.head++
+		o.flush(o.head)
+	}
+}
+
+// flushAll releases whatever is still buffered, in plan order.
+func (o *orderedSink) flushAll() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := o.head; i < o.end; i++ {
+		o.flush(i)
+	}
+	o.head = o.end
+}
+
+func (o *orderedSink) flush(i int) {
+	for _, e := range o.bufs[i] {
+		o.emit(e)
+	}
+	delete(o.bufs, i)
+}
+
+// runTask runs t under its timeout and retry policy, logging each attempt.
+func runTask(ctx context.Context, t Task, st *State, emit events.Sink) error {
+	attempts := t.Retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	retryable := t.Retry.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	backoff := t.Retry.Backoff
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			emit.Log(fmt.Sprintf("Attempt %d/%d", attempt, attempts))
+		}
+		err = runAttempt(ctx, t, st, emit)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if attempt == attempts || !retryable(err) {
+			break
+		}
+		wait := backoff << (attempt - 1)
+		emit.Warn(fmt.Sprintf("Attempt %d/%d failed: %s; retrying in %s", attempt, attempts, err.Error(), wait))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func runAttempt(ctx context.Context, t Task, st *State, emit events.Sink) error {
+	if t.Timeout <= 0 {
+		return t.Run(ctx, st, emit)
+	}
+	tctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	err := t.Run(tctx, st, emit)
+	if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", t.Timeout, context.DeadlineExceeded)
+	}
+	return err
+}
@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nvimwiz/internal/events"
+)
+
+// recorder runs fake tasks and remembers the order they started and
+// finished in, and how many ran at once.
+type recorder struct {
+	mu      sync.Mutex
+	log     []string
+	running int
+	peak    int
+}
+
+func (r *recorder) task(id string, deps []string, d time.Duration, err error) Task {
+	return Task{ID: id, Name: id, Deps: deps, Run: func(ctx context.Context, st *State, emit events.Sink) error {
+		r.mu.Lock()
+		r.log = append(r.log, "start "+id)
+		r.running++
+		if r.running > r.peak {
+			r.peak = r.running
+		}
+		r.mu.Unlock()
+		emit.Log(id + " working")
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+		r.mu.Lock()
+		r.running--
+		r.log = append(r.log, "end "+id)
+		r.mu.Unlock()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}}
+}
+
+func (r *recorder) before(t *testing.T, a, b string) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ia, ib := -1, -1
+	for i, l := range r.log {
+		if l == a && ia < 0 {
+			ia = i
+		}
+		if l == b && ib < 0 {
+			ib = i
+		}
+	}
+	if ia < 0 || ib < 0 || ia > ib {
+		t.Fatalf("want %q before %q, log %v", a, b, r.log)
+	}
+}
+
+func (r *recorder) ran(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.log {
+		if l == "start "+id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunFromHonoursDeps(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{
+		r.task("a", nil, 30*time.Millisecond, nil),
+		r.task("b", nil, 30*time.Millisecond, nil),
+		r.task("c", []string{"a", "missing"}, 0, nil),
+		r.task("d", []string{"b", "c"}, 0, nil),
+	}
+	_, failedAt, err := RunFrom(context.Background(), plan, nil, 0, 3, nil)
+	if err != nil || failedAt != -1 {
+		t.Fatalf("RunFrom = %d, %v", failedAt, err)
+	}
+	r.before(t, "end a", "start c")
+	r.before(t, "end b", "start d")
+	r.before(t, "end c", "start d")
+	if r.peak != 2 {
+		t.Fatalf("peak concurrency %d, want a and b together", r.peak)
+	}
+}
+
+func TestRunFromLimitsWorkers(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{}
+	for i := 0; i < 6; i++ {
+		plan = append(plan, r.task(fmt.Sprint("t", i), nil, 10*time.Millisecond, nil))
+	}
+	if _, _, err := RunFrom(context.Background(), plan, nil, 0, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if r.peak != 2 {
+		t.Fatalf("peak concurrency %d, want 2", r.peak)
+	}
+}
+
+func TestRunFromFailure(t *testing.T) {
+	r := &recorder{}
+	boom := errors.New("boom")
+	plan := []Task{
+		r.task("a", nil, 0, nil),
+		r.task("b", []string{"a"}, 0, boom),
+		r.task("c", []string{"b"}, 0, nil),
+		r.task("d", nil, 0, nil),
+	}
+	_, failedAt, err := RunFrom(context.Background(), plan, nil, 0, 1, nil)
+	if failedAt != 1 || !errors.Is(err, boom) {
+		t.Fatalf("RunFrom = %d, %v; want 1, boom", failedAt, err)
+	}
+	if r.ran("c") || r.ran("d") {
+		t.Fatalf("tasks started after the failure: %v", r.log)
+	}
+}
+
+func TestRunFromStartSkipsEarlierDeps(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{
+		r.task("a", nil, 0, errors.New("a must not run")),
+		r.task("b", []string{"a"}, 0, nil),
+	}
+	if _, failedAt, err := RunFrom(context.Background(), plan, nil, 1, 2, nil); err != nil || failedAt != -1 {
+		t.Fatalf("RunFrom = %d, %v", failedAt, err)
+	}
+	if r.ran("a") || !r.ran("b") {
+		t.Fatalf("log %v, want only b", r.log)
+	}
+}
+
+func TestRunFromCycle(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{
+		r.task("a", []string{"b"}, 0, nil),
+		r.task("b", []string{"a"}, 0, nil),
+	}
+	_, failedAt, err := RunFrom(context.Background(), plan, nil, 0, 2, nil)
+	if failedAt != 0 || err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("RunFrom = %d, %v; want a cycle at 0", failedAt, err)
+	}
+}
+
+func TestRunFromCancel(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{
+		r.task("a", nil, time.Minute, nil),
+		r.task("b", []string{"a"}, 0, nil),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { time.Sleep(20 * time.Millisecond); cancel() }()
+	_, failedAt, err := RunFrom(ctx, plan, nil, 0, 2, nil)
+	if failedAt != 0 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunFrom = %d, %v; want 0, canceled", failedAt, err)
+	}
+}
+
+func TestRunFromGroupsLogsInPlanOrder(t *testing.T) {
+	r := &recorder{}
+	plan := []Task{
+		r.task("slow", nil, 40*time.Millisecond, nil),
+		r.task("fast", nil, 0, nil),
+	}
+	var mu sync.Mutex
+	got := []string{}
+	emit := events.Sink(func(e events.Event) {
+		if e.Kind == events.Log {
+			mu.Lock()
+			got = append(got, e.Message)
+			mu.Unlock()
+		}
+	})
+	if _, _, err := RunFrom(context.Background(), plan, nil, 0, 2, emit); err != nil {
+		t.Fatal(err)
+	}
+	r.before(t, "end fast", "end slow")
+	if strings.Join(got, ",") != "slow working,fast working" {
+		t.Fatalf("logs %v, want slow's first", got)
+	}
+}
+
+func TestRunTaskRetries(t *testing.T) {
+	tries := 0
+	transient := fmt.Errorf("attempt: %w", context.DeadlineExceeded)
+	task := Task{
+		Retry: RetryPolicy{Attempts: 3, Backoff: time.Millisecond},
+		Run: func(ctx context.Context, st *State, emit events.Sink) error {
+			tries++
+			if tries < 3 {
+				return transient
+			}
+			return nil
+		},
+	}
+	if err := runTask(context.Background(), task, &State{}, nil); err != nil || tries != 3 {
+		t.Fatalf("runTask = %v after %d tries", err, tries)
+	}
+
+	tries = 0
+	task.Run = func(ctx context.Context, st *State, emit events.Sink) error {
+		tries++
+		return errors.New("checksum mismatch")
+	}
+	if err := runTask(context.Background(), task, &State{}, nil); err == nil || tries != 1 {
+		t.Fatalf("runTask retried a permanent error: %v after %d tries", err, tries)
+	}
+}
+
+func TestRunAttemptTimeout(t *testing.T) {
+	task := Task{
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context, st *State, emit events.Sink) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	err := runAttempt(context.Background(), task, &State{}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after") {
+		t.Fatalf("runAttempt = %v", err)
+	}
+}
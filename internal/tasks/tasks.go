@@ -6,64 +6,261 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/env"
+	"nvimwiz/internal/events"
 	"nvimwiz/internal/install"
 	"nvimwiz/internal/nvimcfg"
 	"nvimwiz/internal/profile"
+	"nvimwiz/internal/receipt"
 )
 
 type State struct {
-	NvimPath string
-	RgPath   string
-	FdPath   string
+	mu sync.Mutex
+
+	// Paths maps installed tool names to their command paths.
+	Paths map[string]string `json:"paths,omitempty"`
+
+	// BoundNvimPath is the Neovim binary a safe build's launcher runs.
+	BoundNvimPath string `json:"boundNvimPath,omitempty"`
+}
+
+func (st *State) setPath(tool, path string) {
+	if st.Paths == nil {
+		st.Paths = map[string]string{}
+	}
+	st.Paths[tool] = path
+}
+
+// copyFrom replaces st's resolved paths with those of src. Only src is
+// locked; callers guard st themselves.
+func (st *State) copyFrom(src *State) {
+	src.update(func(src *State) {
+		st.Paths = map[string]string{}
+		for k, v := range src.Paths {
+			st.Paths[k] = v
+		}
+		st.BoundNvimPath = src.BoundNvimPath
+	})
+}
+
+func (st *State) update(fn func(st *State)) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	fn(st)
 }
 
 type Task struct {
+	// ID identifies the task for dependency declarations.
+	ID   string
 	Name string
-	Run  func(ctx context.Context, st *State, log func(string)) error
+	// Deps lists the IDs of tasks that must finish first. IDs that are not
+	// part of the plan are ignored.
+	Deps []string
+	// Timeout bounds a single attempt; zero means no limit.
+	Timeout time.Duration
+	Retry   RetryPolicy
+	Run     func(ctx context.Context, st *State, emit events.Sink) error
+	Preview func(ctx context.Context) ([]string, error)
+}
+
+var (
+	installPolicy = RetryPolicy{Attempts: 2, Backoff: 5 * time.Second}
+	syncPolicy    = RetryPolicy{Attempts: 2, Backoff: 10 * time.Second, Retryable: retryAnyError}
+)
+
+// InstallOptions is what the installer for tool gets from p: the checksum
+// policy and signing keys, the pinned version, the release source and the
+// offline bundle.
+func InstallOptions(p profile.Profile, tool string) install.Options {
+	rs := p.ToolSource(tool)
+	return install.Options{
+		Verify:      p.Verify,
+		SigningKeys: p.ToolSigningKeys(tool),
+		Version:     p.ToolVersion(tool),
+		Source:      install.Source{APIBase: rs.APIBase, Rewrites: rs.Rewrites, Mirrors: rs.Mirrors, TokenEnv: rs.TokenEnv},
+		Bundle:      p.Bundle,
+	}
+}
+
+// SignedVerifiable reports whether any tool p installs has a signing key,
+// built in or pinned in the profile. Without one, Verify "signed" fails
+// every install.
+func SignedVerifiable(p profile.Profile) bool {
+	for _, t := range install.Tools() {
+		if p.Features[t.FeatureID] && len(t.SigningKeys)+len(p.ToolSigningKeys(t.Name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NvimPins lists the Neovim versions saved profiles' launchers depend on:
+// the version each profile asks for and the binary its launcher execs, so
+// a launcher bound to "stable" keeps the tag it resolved to.
+func NvimPins(cat catalog.Catalog) ([]install.NvimPin, error) {
+	bindings, err := profile.NvimBindings(cat)
+	if err != nil {
+		return nil, err
+	}
+	pins := []install.NvimPin{}
+	for _, b := range bindings {
+		pins = append(pins, install.NvimPin{
+			Owner: "launcher " + strconv.Quote(b.AppName) + " (profile " + b.Profile + ")",
+			Spec:  b.Spec,
+			Bin:   env.NvimAppLauncherTarget(b.AppName),
+		})
+	}
+	return pins, nil
 }
 
 func Plan(p profile.Profile, cat catalog.Catalog) []Task {
 	plan := []Task{}
 
-	if p.Features["install.neovim"] {
+	installIDs := []string{}
+	for _, tool := range install.Tools() {
+		if !p.Features[tool.FeatureID] {
+			continue
+		}
+		tool := tool
+		installIDs = append(installIDs, tool.FeatureID)
 		plan = append(plan, Task{
-			Name: "Install Neovim",
-			Run: func(ctx context.Context, st *State, log func(string)) error {
-				path, err := install.InstallNeovim(ctx, p.Verify, log)
+			ID:      tool.FeatureID,
+			Timeout: 10 * time.Minute,
+			Retry:   installPolicy,
+			Name:    "Install " + tool.Title,
+			Preview: func(ctx context.Context) ([]string, error) {
+				return previewInstall(ctx, tool.FeatureID, InstallOptions(p, tool.Name))
+			},
+			Run: func(ctx context.Context, st *State, emit events.Sink) error {
+				path, err := install.InstallTool(ctx, tool, InstallOptions(p, tool.Name), emit)
 				if err != nil {
 					return err
 				}
-				st.NvimPath = path
+				st.update(func(st *State) { st.setPath(tool.Name, path) })
 				return nil
 			},
 		})
 	}
 
-	if p.Features["install.ripgrep"] {
+	// npm-based servers need Node.js; install it when the system has none
+	// recent enough, even if install.node is off. The plan depends on the
+	// profile alone, so a resumed run sees the same tasks: whether the
+	// system Node.js will do is only checked when the task runs.
+	nodeAuto := !p.Features[install.NodeFeatureID] && install.NeedsNode(p.Features)
+	if p.Features[install.NodeFeatureID] || nodeAuto {
+		name := "Install Node.js"
+		if nodeAuto {
+			name += " (needed by npm language servers, unless the system has it)"
+		}
+		installIDs = append(installIDs, install.NodeFeatureID)
 		plan = append(plan, Task{
-			Name: "Install ripgrep",
-			Run: func(ctx context.Context, st *State, log func(string)) error {
-				path, err := install.InstallRipgrep(ctx, p.Verify, log)
+			ID:      install.NodeFeatureID,
+			Timeout: 10 * time.Minute,
+			Retry:   installPolicy,
+			Name:    name,
+			Preview: func(ctx context.Context) ([]string, error) {
+				if nodeAuto && install.SystemNodeOK(ctx) {
+					return []string{"Found: a system Node.js recent enough for npm language servers", "Action: skip"}, nil
+				}
+				return previewInstall(ctx, install.NodeFeatureID, InstallOptions(p, "node"))
+			},
+			Run: func(ctx context.Context, st *State, emit events.Sink) error {
+				if nodeAuto && install.SystemNodeOK(ctx) {
+					emit.Log("System Node.js is recent enough for npm language servers, skipping")
+					return nil
+				}
+				path, err := install.InstallNode(ctx, InstallOptions(p, "node"), emit)
 				if err != nil {
 					return err
 				}
-				st.RgPath = path
+				st.update(func(st *State) { st.setPath("node", path) })
 				return nil
 			},
 		})
 	}
 
-	if p.Features["install.fd"] {
+	if p.Features["lsp.servers"] {
+		lastNpm := ""
+		for _, srv := range install.Servers() {
+			if !p.Features[srv.FeatureID] {
+				continue
+			}
+			srv := srv
+			id := "lsp.server." + strings.TrimPrefix(srv.FeatureID, "lsp.")
+			deps := []string{}
+			// npm installs share one prefix and must not run concurrently.
+			if srv.Method == install.Npm {
+				deps = append(deps, install.NodeFeatureID)
+				if lastNpm != "" {
+					deps = append(deps, lastNpm)
+				}
+				lastNpm = id
+			}
+			plan = append(plan, Task{
+				ID:      id,
+				Timeout: 10 * time.Minute,
+				Retry:   installPolicy,
+				Name:    "Install language server " + srv.Name,
+				Deps:    deps,
+				Preview: func(ctx context.Context) ([]string, error) { return previewServer(srv) },
+				Run: func(ctx context.Context, st *State, emit events.Sink) error {
+					if cur := install.StatusForServer(srv); cur.Installed {
+						emit.Log(srv.Name + " found at " + cur.Path + ", skipping")
+						return nil
+					}
+					_, err := install.InstallServer(ctx, srv, InstallOptions(p, srv.Name), emit)
+					var unsupported *install.UnsupportedError
+					if errors.Is(err, install.ErrMissingToolchain) || errors.Is(err, install.ErrOffline) || errors.As(err, &unsupported) {
+						emit.Warn(err.Error() + "; skipping " + srv.Name)
+						return nil
+					}
+					return err
+				},
+			})
+		}
+	}
+
+	if bound := p.BoundNvimVersion(); bound != "" {
+		appName := p.EffectiveAppName()
 		plan = append(plan, Task{
-			Name: "Install fd",
-			Run: func(ctx context.Context, st *State, log func(string)) error {
-				path, err := install.InstallFd(ctx, p.Verify, log)
+			ID:      "launcher.nvim",
+			Timeout: 10 * time.Minute,
+			Retry:   installPolicy,
+			Name:    "Install Neovim " + bound + " for " + appName,
+			// Both installers unpack into ~/.local/nvim; don't let them race.
+			Deps: []string{"install.neovim"},
+			Preview: func(ctx context.Context) ([]string, error) {
+				return previewBoundNvim(ctx, bound, appName)
+			},
+			Run: func(ctx context.Context, st *State, emit events.Sink) error {
+				opts := InstallOptions(p, "neovim")
+				opts.Version = bound
+				v, err := install.EnsureNeovimVersion(ctx, opts, emit)
 				if err != nil {
 					return err
 				}
-				st.FdPath = path
+				st.update(func(st *State) { st.BoundNvimPath = v.Bin })
+
+				launcher, err := env.NvimAppLauncherPath(appName)
+				if err != nil {
+					return err
+				}
+				if _, err := os.Stat(launcher); err != nil {
+					return nil
+				}
+				if _, err := env.CreateNvimAppLauncher(appName, v.Bin); err != nil {
+					return err
+				}
+				if err := receipt.RecordLauncher(appName, launcher, v.Bin); err != nil {
+					emit.Warn("Could not write the install receipt for " + appName + ": " + err.Error())
+				}
+				emit.Log("Launcher " + launcher + " now runs Neovim " + v.Tag)
 				return nil
 			},
 		})
@@ -71,11 +268,15 @@ func Plan(p profile.Profile, cat catalog.Catalog) []Task {
 
 	if p.Features["config.write"] {
 		plan = append(plan, Task{
-			Name: "Write Neovim config",
-			Run: func(ctx context.Context, st *State, log func(string)) error {
+			ID:      "config.write",
+			Timeout: time.Minute,
+			Name:    "Write Neovim config",
+			Deps:    installIDs,
+			Preview: func(ctx context.Context) ([]string, error) { return previewConfigWrite(p, cat) },
+			Run: func(ctx context.Context, st *State, emit events.Sink) error {
 				_ = ctx
 				_ = st
-				return nvimcfg.Write(p, cat, log)
+				return nvimcfg.Write(p, cat, emit)
 			},
 		})
 	}
@@ -84,9 +285,20 @@ func Plan(p profile.Profile, cat catalog.Catalog) []Task {
 	// For safe builds we MUST set NVIM_APPNAME so Neovim uses the correct config/runtimepath.
 	if p.Features["config.lazysync"] && p.Features["config.write"] {
 		plan = append(plan, Task{
-			Name: "Sync plugins",
-			Run: func(ctx context.Context, st *State, log func(string)) error {
-				bin := st.NvimPath
+			ID:      "config.lazysync",
+			Timeout: 15 * time.Minute,
+			Retry:   syncPolicy,
+			Name:    "Sync plugins",
+			Deps:    []string{"config.write", "install.neovim", "launcher.nvim"},
+			Preview: func(ctx context.Context) ([]string, error) { return previewLazySync(p) },
+			Run: func(ctx context.Context, st *State, emit events.Sink) error {
+				var bin string
+				st.update(func(st *State) {
+					bin = st.BoundNvimPath
+					if bin == "" {
+						bin = st.Paths["neovim"]
+					}
+				})
 				if bin == "" {
 					path, err := exec.LookPath("nvim")
 					if err == nil {
@@ -115,47 +327,19 @@ func Plan(p profile.Profile, cat catalog.Catalog) []Task {
 				cmd.Env = append(os.Environ(), "NVIM_APPNAME="+p.EffectiveAppName())
 
 				b, err := cmd.CombinedOutput()
-				if log != nil && len(b) > 0 {
-					log(string(b))
+				if len(b) > 0 {
+					emit.Log(string(b))
 				}
 				return err
 			},
 		})
 	}
 
+	applyProfilePolicies(plan, p)
 	return plan
 }
 
-func RunFrom(ctx context.Context, plan []Task, st *State, start int, log func(string), progress func(done, total int)) (*State, int, error) {
-	if st == nil {
-		st = &State{}
-	}
-	total := len(plan)
-	if start < 0 {
-		start = 0
-	}
-	if start > total {
-		start = total
-	}
-	for i := start; i < total; i++ {
-		t := plan[i]
-		if log != nil {
-			log("== " + t.Name + " ==")
-		}
-		if err := t.Run(ctx, st, log); err != nil {
-			if log != nil {
-				log("Error: " + err.Error())
-			}
-			return st, i, err
-		}
-		if progress != nil {
-			progress(i+1, total)
-		}
-	}
-	return st, -1, nil
-}
-
-func RunAll(ctx context.Context, plan []Task, log func(string), progress func(done, total int)) error {
-	_, _, err := RunFrom(ctx, plan, nil, 0, log, progress)
+func RunAll(ctx context.Context, plan []Task, workers int, emit events.Sink) error {
+	_, _, err := RunFrom(ctx, plan, nil, 0, workers, emit)
 	return err
 }
@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nvimwiz/internal/catalog"
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/profile"
+)
+
+// TestPlanIgnoresSystemNode checks that the plan, and so its hash, does
+// not change when a Node.js appears on PATH between runs.
+func TestPlanIgnoresSystemNode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cat := catalog.Get()
+	p := profile.Default(cat)
+	p.Features[install.NodeFeatureID] = false
+	p.Features["lsp.servers"] = true
+	p.Features["lsp.python"] = true
+
+	t.Setenv("PATH", t.TempDir())
+	without := Plan(p, cat)
+
+	bin := t.TempDir()
+	for name, script := range map[string]string{
+		"node": "#!/bin/sh\necho v22.1.0\n",
+		"npm":  "#!/bin/sh\n",
+	} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	if !install.SystemNodeOK(context.Background()) {
+		t.Fatal("fake node not picked up")
+	}
+	with := Plan(p, cat)
+
+	if PlanHash(p, without) != PlanHash(p, with) {
+		t.Fatalf("plan changed with a system node: %v vs %v", ids(without), ids(with))
+	}
+	if !contains(ids(with), install.NodeFeatureID) {
+		t.Fatalf("plan %v has no %s task", ids(with), install.NodeFeatureID)
+	}
+}
+
+func ids(plan []Task) []string {
+	out := []string{}
+	for _, t := range plan {
+		out = append(out, t.ID)
+	}
+	return out
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSignedVerifiable(t *testing.T) {
+	p := profile.Profile{Features: map[string]bool{"install.ripgrep": true}}
+	if SignedVerifiable(p) {
+		t.Fatal("signed offered without any key")
+	}
+	p.SigningKeys = map[string][]string{"fd": {"RWQkey"}}
+	if SignedVerifiable(p) {
+		t.Fatal("a key for a disabled tool made signed usable")
+	}
+	p.SigningKeys = map[string][]string{"ripgrep": {"RWQkey"}}
+	if !SignedVerifiable(p) {
+		t.Fatal("a key for an enabled tool was not enough")
+	}
+	p.SigningKeys = map[string][]string{"*": {"RWQkey"}}
+	if !SignedVerifiable(p) {
+		t.Fatal("a key for every tool was not enough")
+	}
+}
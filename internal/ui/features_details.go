@@ -25,6 +25,15 @@ func (w *Wizard) renderDetails(it itemRef) {
 			if len(f.Requires) > 0 {
 				lines = append(lines, "Requires: "+strings.Join(f.Requires, ", "), "")
 			}
+
+			if strings.EqualFold(f.Category, "Install") {
+				lines = append(lines, w.installDetailsLines(it.ID)...)
+				lines = append(lines, "")
+			}
+			if srv := w.lspServerLines(it.ID); len(srv) > 0 {
+				lines = append(lines, srv...)
+				lines = append(lines, "")
+			}
 		}
 		lines = append(lines, "Current: "+w.itemActionLabel(it))
 		w.detailView.SetText(strings.Join(trimTrailingEmpty(lines), "\n"))
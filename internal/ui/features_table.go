@@ -133,10 +133,7 @@ func (w *Wizard) itemActionLabel(it itemRef) string {
 		if !on {
 			return "Skip"
 		}
-		if installToolPresent(it.ID) {
-			return "Update"
-		}
-		return "Install"
+		return w.installEnabledLabel(it.ID)
 	}
 
 	if on {
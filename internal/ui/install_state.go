@@ -7,19 +7,18 @@ import (
 	"runtime"
 
 	"nvimwiz/internal/env"
+	"nvimwiz/internal/install"
 )
 
 func installFeatureBinary(featureID string) (string, bool) {
-	switch featureID {
-	case "install.neovim":
-		return "nvim", true
-	case "install.ripgrep":
-		return "rg", true
-	case "install.fd":
-		return "fd", true
-	default:
+	if featureID == install.NodeFeatureID {
+		return "node", true
+	}
+	t, ok := install.ToolByFeature(featureID)
+	if !ok {
 		return "", false
 	}
+	return t.Binary, true
 }
 
 func installFeaturePresent(featureID string) bool {
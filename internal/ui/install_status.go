@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"nvimwiz/internal/install"
+	"nvimwiz/internal/tasks"
 )
 
 func (w *Wizard) refreshInstallStatusAsync() {
@@ -22,11 +23,17 @@ func (w *Wizard) refreshInstallStatusAsync() {
 	w.installStatusLast = time.Now()
 
 	ids := w.installFeatureIDs()
+	opts := map[string]install.Options{}
+	for _, id := range ids {
+		if tool, ok := install.ToolForFeature(id); ok {
+			opts[id] = tasks.InstallOptions(w.p, tool)
+		}
+	}
 	go func(ids []string) {
 		res := map[string]install.ToolStatus{}
 		for _, id := range ids {
 			ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
-			st, ok := install.StatusForFeature(ctx, id)
+			st, ok := install.StatusForFeature(ctx, id, opts[id])
 			cancel()
 			if ok {
 				res[id] = st
@@ -65,9 +72,12 @@ func (w *Wizard) installEnabledLabel(featureID string) string {
 			if !st.Present {
 				return "Install"
 			}
-			if st.LatestOK && st.CurrentOK && strings.TrimSpace(st.CurrentVersion) != "" && strings.TrimSpace(st.LatestVersion) != "" && st.CurrentVersion != st.LatestVersion {
+			if st.TargetOK && st.CurrentOK && strings.TrimSpace(st.CurrentVersion) != "" && st.CurrentVersion != st.TargetVersion {
 				return "Update"
 			}
+			if st.Pinned != "" && st.UpToDate() {
+				return "Pinned"
+			}
 			return "Installed"
 		}
 	}
@@ -92,7 +102,12 @@ func (w *Wizard) installDetailsLines(featureID string) []string {
 	curOK := false
 	latest := ""
 	latestOK := false
+	pinned := ""
 	err := ""
+	asset := ""
+	assetNote := ""
+	managed := ""
+	missing := []string{}
 
 	if w.installStatus != nil {
 		if st, ok := w.installStatus[featureID]; ok {
@@ -100,9 +115,19 @@ func (w *Wizard) installDetailsLines(featureID string) []string {
 			path = st.Path
 			cur = st.CurrentVersion
 			curOK = st.CurrentOK
-			latest = st.LatestVersion
-			latestOK = st.LatestOK
+			latest = st.TargetVersion
+			latestOK = st.TargetOK
+			pinned = st.Pinned
 			err = st.Error
+			asset = st.Asset
+			assetNote = st.AssetNote
+			missing = st.Missing
+			if st.Managed {
+				managed = "installed by nvimwiz"
+				if !st.InstalledAt.IsZero() {
+					managed += " on " + st.InstalledAt.Format("2006-01-02")
+				}
+			}
 		}
 	}
 
@@ -128,11 +153,23 @@ func (w *Wizard) installDetailsLines(featureID string) []string {
 	lines := []string{}
 	if !present || strings.TrimSpace(path) == "" {
 		lines = append(lines, "Installed path: not found")
+	} else if managed != "" {
+		lines = append(lines, "Installed path: "+path+" ("+managed+")")
 	} else {
 		lines = append(lines, "Installed path: "+path)
 	}
+	if len(missing) > 0 {
+		lines = append(lines, "Receipt lists missing files: "+strings.Join(missing, ", "))
+	}
+	if tool, ok := install.ToolForFeature(featureID); ok && pinned == "" {
+		pinned = w.p.ToolVersion(tool)
+	}
 	lines = append(lines, "Current version: "+curDisp)
-	lines = append(lines, "Latest version: "+latestDisp)
+	if pinned != "" {
+		lines = append(lines, "Pinned version: "+pinned+" (resolves to "+latestDisp+")")
+	} else {
+		lines = append(lines, "Latest version: "+latestDisp)
+	}
 
 	if !latestOK && strings.TrimSpace(err) != "" {
 		errLine := strings.TrimSpace(err)
@@ -142,14 +179,29 @@ func (w *Wizard) installDetailsLines(featureID string) []string {
 		}
 		lines = append(lines, "Latest check: "+errLine)
 	}
+	if asset != "" {
+		line := "Build: " + asset
+		if assetNote != "" {
+			line += " (" + assetNote + ")"
+		}
+		lines = append(lines, line)
+	} else if assetNote != "" {
+		lines = append(lines, "Build: none fits this system. "+assetNote)
+	}
 
 	apply := ""
 	if !enabled {
 		apply = "Apply: skipped. This tool will not be installed or updated."
+	} else if pinned != "" && !present {
+		apply = "Apply: will download and install the pinned release " + pinned + "."
+	} else if pinned != "" && labelEnabled == "Update" {
+		apply = "Apply: will switch the installed version to the pinned release " + pinned + "."
 	} else if !present {
 		apply = "Apply: will download and install the latest release."
 	} else if labelEnabled == "Update" {
 		apply = "Apply: will download and install the latest release (update)."
+	} else if pinned != "" {
+		apply = "Apply: the pinned release is already installed; the download will be skipped."
 	} else {
 		apply = "Apply: will check for the latest release and skip the download if you're already up to date."
 	}
@@ -160,14 +212,6 @@ func (w *Wizard) installDetailsLines(featureID string) []string {
 }
 
 func installCommandForFeature(featureID string) string {
-	switch featureID {
-	case "install.neovim":
-		return "nvim"
-	case "install.ripgrep":
-		return "rg"
-	case "install.fd":
-		return "fd"
-	default:
-		return ""
-	}
+	cmd, _ := installCommandName(featureID)
+	return cmd
 }
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"nvimwiz/internal/install"
+)
+
+// refreshLSPStatusAsync looks for every language server and a usable
+// Node.js off the UI goroutine; the details pane reads the cached result.
+func (w *Wizard) refreshLSPStatusAsync() {
+	if w.lspStatusRunning {
+		return
+	}
+	if !w.lspStatusLast.IsZero() && time.Since(w.lspStatusLast) < 5*time.Second {
+		return
+	}
+
+	w.lspStatusRunning = true
+	w.lspStatusLast = time.Now()
+
+	go func() {
+		res := map[string]install.ServerStatus{}
+		for _, srv := range install.Servers() {
+			res[srv.Name] = install.StatusForServer(srv)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+		nodeOK := install.NodeBinDir() != "" || install.SystemNodeOK(ctx)
+		cancel()
+
+		w.app.QueueUpdateDraw(func() {
+			w.lspStatus = res
+			w.lspNodeOK = nodeOK
+			w.lspStatusRunning = false
+			if w.currentCategory == "LSP" {
+				w.renderSelectedDetails()
+			}
+		})
+	}()
+}
+
+// renderSelectedDetails redraws the details pane for the selected row.
+func (w *Wizard) renderSelectedDetails() {
+	if w.featureTable == nil {
+		return
+	}
+	row, _ := w.featureTable.GetSelection()
+	if it, ok := w.itemAtRow(row); ok {
+		w.renderDetails(it)
+	}
+}
+
+func (w *Wizard) lspServerLines(featureID string) []string {
+	servers := install.ServersForFeature(featureID)
+	if len(servers) == 0 {
+		return nil
+	}
+	if w.lspStatus == nil {
+		w.refreshLSPStatusAsync()
+		return []string{"Language servers: checking..."}
+	}
+	managed := w.p.Features["lsp.servers"]
+
+	lines := []string{"Language servers:"}
+	needNode := false
+	for _, srv := range servers {
+		st := w.lspStatus[srv.Name]
+		if srv.Method == install.Npm && !st.Installed {
+			needNode = true
+		}
+		line := "  " + srv.Name + ": "
+		switch {
+		case st.Managed:
+			line += "installed (" + st.Path + ")"
+		case st.Installed:
+			line += "found on PATH (" + st.Path + ")"
+		case managed:
+			line += "missing, Apply installs it via " + srv.Method.String()
+		default:
+			line += "missing (enable \"Install servers\" or install " + srv.Binaries[0] + " yourself)"
+		}
+		if len(st.Missing) > 0 {
+			line += "; receipt lists missing files: " + strings.Join(st.Missing, ", ")
+		}
+		lines = append(lines, line)
+	}
+	if managed && needNode && !w.lspNodeOK {
+		lines = append(lines, fmt.Sprintf("  Node.js: none %d+ found, Apply installs it first (%s)", install.MinNodeMajor, install.NodeFeatureID))
+	}
+	return lines
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/rivo/tview"
 
 	"nvimwiz/internal/env"
+	"nvimwiz/internal/install"
 	"nvimwiz/internal/nvimcfg"
+	"nvimwiz/internal/receipt"
 )
 
 func (w *Wizard) showNextSteps() {
@@ -25,6 +27,19 @@ func (w *Wizard) showNextSteps() {
 	cfgDir, _ := nvimcfg.ConfigDirForProfile(w.p)
 	localBin, _ := env.LocalBin()
 
+	boundVersion := ""
+	boundBin := ""
+	if safe {
+		boundVersion = w.p.BoundNvimVersion()
+	}
+	if boundVersion != "" {
+		if w.taskState != nil && w.taskState.BoundNvimPath != "" {
+			boundBin = w.taskState.BoundNvimPath
+		} else if v, ok := install.InstalledNvimVersion(boundVersion); ok {
+			boundBin = v.Bin
+		}
+	}
+
 	canLauncher := safe && runtime.GOOS != "windows"
 	launcherName := ""
 	launcherPath := ""
@@ -49,6 +64,13 @@ func (w *Wizard) showNextSteps() {
 		if cfgDir != "" {
 			lines = append(lines, "Config dir: "+cfgDir)
 		}
+		if boundVersion != "" {
+			if boundBin != "" {
+				lines = append(lines, "Neovim: "+boundVersion+" ("+boundBin+")")
+			} else {
+				lines = append(lines, "Neovim: "+boundVersion+" (not installed yet, run Apply)")
+			}
+		}
 
 		lines = append(lines, "")
 
@@ -108,7 +130,7 @@ func (w *Wizard) showNextSteps() {
 			return
 		}
 		if label == "Create launcher" {
-			path, err := env.CreateNvimAppLauncher(appName)
+			path, err := env.CreateNvimAppLauncher(appName, boundBin)
 			if err != nil {
 				modal.SetText(render("Launcher error: " + err.Error()))
 				w.app.SetFocus(modal)
@@ -116,7 +138,11 @@ func (w *Wizard) showNextSteps() {
 			}
 			launcherExists = true
 			launcherPath = path
-			modal.SetText(render("Launcher created: " + path))
+			msg := "Launcher created: " + path
+			if err := receipt.RecordLauncher(appName, path, boundBin); err != nil {
+				msg += " (receipt not written: " + err.Error() + ")"
+			}
+			modal.SetText(render(msg))
 			w.app.SetFocus(modal)
 		}
 	})
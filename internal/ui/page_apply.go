@@ -2,6 +2,7 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/rivo/tview"
 
+	"nvimwiz/internal/events"
+	"nvimwiz/internal/install"
 	"nvimwiz/internal/tasks"
 )
 
@@ -39,9 +42,14 @@ func (w *Wizard) pageApply() tview.Primitive {
 	buttonsFailed.AddButton("Quit", func() { w.app.Stop() })
 	buttonsFailed.SetButtonsAlign(tview.AlignCenter)
 
+	buttonsRunning := tview.NewForm()
+	buttonsRunning.AddButton("Cancel", func() { w.cancelApply() })
+	buttonsRunning.SetButtonsAlign(tview.AlignCenter)
+
 	w.applyButtons = tview.NewPages()
 	w.applyButtons.AddPage("normal", buttonsNormal, true, true)
 	w.applyButtons.AddPage("failed", buttonsFailed, true, false)
+	w.applyButtons.AddPage("running", buttonsRunning, true, false)
 
 	wrap := tview.NewFlex().SetDirection(tview.FlexRow)
 	wrap.AddItem(w.logView, 0, 1, false)
@@ -61,6 +69,35 @@ func (w *Wizard) showApplyButtonsFailed(failed bool) {
 	w.applyButtons.SwitchToPage("normal")
 }
 
+func (w *Wizard) cancelApply() {
+	if w.applyCancel == nil {
+		return
+	}
+	w.applyCancel()
+	fmt.Fprintln(w.logView, "")
+	fmt.Fprintln(w.logView, "Cancelling...")
+	w.logView.ScrollToEnd()
+}
+
+// resumeLastRun continues the run recorded in the journal, restoring the
+// tool paths it had already resolved.
+func (w *Wizard) resumeLastRun(j *tasks.Journal) {
+	plan := tasks.Plan(w.p, w.cat)
+	idx, ok := j.ResumeIndex(w.p, plan)
+	if !ok {
+		w.message("Resume last run", "The last run can no longer be resumed because the profile changed since. Start a new run instead.")
+		return
+	}
+	w.taskPlan = plan
+	w.taskState = j.ResumeState()
+	w.journal = j
+	w.applyFailedIndex = idx
+	w.logView.SetText("")
+	w.progressView.SetText("")
+	w.gotoPage("apply")
+	w.startApplyFrom(idx, false)
+}
+
 func (w *Wizard) startApply() {
 	w.startApplyFrom(0, true)
 }
@@ -88,6 +125,10 @@ func (w *Wizard) startApplyFrom(startIndex int, reset bool) {
 		w.taskPlan = tasks.Plan(w.p, w.cat)
 		w.taskState = &tasks.State{}
 		w.applyFailedIndex = -1
+		w.journal = tasks.NewJournal(w.p, w.taskPlan)
+		for _, msg := range tasks.PolicyWarnings(w.p) {
+			fmt.Fprintln(w.logView, "[yellow]Warning: "+tview.Escape(msg)+"[-]")
+		}
 	} else {
 		if w.taskPlan == nil || len(w.taskPlan) == 0 {
 			w.taskPlan = tasks.Plan(w.p, w.cat)
@@ -95,6 +136,9 @@ func (w *Wizard) startApplyFrom(startIndex int, reset bool) {
 		if w.taskState == nil {
 			w.taskState = &tasks.State{}
 		}
+		if w.journal == nil {
+			w.journal = tasks.NewJournal(w.p, w.taskPlan)
+		}
 		if startIndex < 0 {
 			startIndex = 0
 		}
@@ -104,7 +148,7 @@ func (w *Wizard) startApplyFrom(startIndex int, reset bool) {
 			return
 		}
 		fmt.Fprintln(w.logView, "")
-		fmt.Fprintln(w.logView, "Retrying: "+w.taskPlan[startIndex].Name)
+		fmt.Fprintln(w.logView, fmt.Sprintf("Resuming at %d/%d: %s", startIndex+1, len(w.taskPlan), w.taskPlan[startIndex].Name))
 		w.logView.ScrollToEnd()
 	}
 
@@ -118,34 +162,38 @@ func (w *Wizard) startApplyFrom(startIndex int, reset bool) {
 	w.progressView.SetText(fmt.Sprintf("%d/%d", startIndex, total))
 
 	ctx, cancel := context.WithCancel(context.Background())
-	_ = cancel
+	w.applyCancel = cancel
+	w.applyButtons.SwitchToPage("running")
 
-	logFn := func(msg string) {
-		w.app.QueueUpdateDraw(func() {
-			fmt.Fprintln(w.logView, strings.TrimRight(msg, "\n"))
-			w.logView.ScrollToEnd()
-		})
-	}
-
-	progressFn := func(done, total int) {
-		w.app.QueueUpdateDraw(func() {
-			w.progressView.SetText(fmt.Sprintf("%d/%d", done, total))
-		})
-	}
+	journal := w.journal
+	emit := journal.Track(w.taskState, w.applyEventSink())
 
 	go func(startAt int, total int) {
 		defer atomic.StoreInt32(&applyRunning, 0)
+		defer cancel()
 		start := time.Now()
-		st, failedAt, err := tasks.RunFrom(ctx, w.taskPlan, w.taskState, startAt, logFn, progressFn)
+		st, failedAt, err := tasks.RunFrom(ctx, w.taskPlan, w.taskState, startAt, tasks.DefaultWorkers, emit)
 		dur := time.Since(start).Round(time.Second)
+		jerr := journal.Finish(st, failedAt, err)
 		w.app.QueueUpdateDraw(func() {
+			if jerr != nil {
+				fmt.Fprintln(w.logView, "Warning: could not save run journal: "+jerr.Error())
+			}
+			w.applyCancel = nil
 			w.taskState = st
 			if err != nil {
 				w.applyFailedIndex = failedAt
 				w.showApplyButtonsFailed(true)
 				fmt.Fprintln(w.logView, "")
-				if failedAt >= 0 && failedAt < len(w.taskPlan) {
+				if errors.Is(err, context.Canceled) && failedAt >= 0 && failedAt < len(w.taskPlan) {
+					w.progressView.SetText(fmt.Sprintf("%d/%d  interrupted", failedAt, total))
+					fmt.Fprintln(w.logView, fmt.Sprintf("Interrupted at %d/%d (%s). Use Retry failed to resume from this step.", failedAt+1, total, w.taskPlan[failedAt].Name))
+				} else if failedAt >= 0 && failedAt < len(w.taskPlan) {
 					fmt.Fprintln(w.logView, fmt.Sprintf("Failed at %d/%d (%s): %s", failedAt+1, total, w.taskPlan[failedAt].Name, err.Error()))
+					var xe *install.ExtractError
+					if errors.As(err, &xe) {
+						fmt.Fprintln(w.logView, xe.Explain())
+					}
 				} else {
 					fmt.Fprintln(w.logView, "Failed: "+err.Error())
 				}
@@ -164,3 +212,42 @@ func (w *Wizard) startApplyFrom(startIndex int, reset bool) {
 		})
 	}(startIndex, total)
 }
+
+// applyEventSink adapts the task event stream to the Run log and the
+// Progress box.
+func (w *Wizard) applyEventSink() events.Sink {
+	return func(e events.Event) {
+		w.app.QueueUpdateDraw(func() {
+			switch e.Kind {
+			case events.TaskStarted:
+				w.progressView.SetText(fmt.Sprintf("%d/%d  %s", e.Step-1, e.Total, e.Task))
+			case events.TaskFinished:
+				w.progressView.SetText(fmt.Sprintf("%d/%d", e.Step, e.Total))
+			case events.DownloadProgress:
+				size := events.FormatBytes(e.Bytes)
+				if e.TotalBytes > 0 {
+					size += " / " + events.FormatBytes(e.TotalBytes)
+				}
+				w.progressView.SetText(fmt.Sprintf("%d/%d  %s  %s  %s", e.Step-1, e.Total, e.Task, e.File, size))
+			}
+
+			line, ok := events.Text(e)
+			if !ok {
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			switch e.Kind {
+			case events.Warning, events.ChecksumSkipped:
+				line = "[yellow]" + tview.Escape(line) + "[-]"
+			case events.TaskFailed:
+				line = "[red]" + tview.Escape(line) + "[-]"
+			case events.ChecksumVerified, events.SignatureVerified:
+				line = "[green]" + tview.Escape(line) + "[-]"
+			default:
+				line = tview.Escape(line)
+			}
+			fmt.Fprintln(w.logView, line)
+			w.logView.ScrollToEnd()
+		})
+	}
+}
@@ -49,8 +49,11 @@ func (w *Wizard) pageFeatures() tview.Primitive {
 		w.pages.RemovePage("picker")
 		w.currentCategory = categoryNames[col]
 		w.renderFeatureTable()
-		if w.currentCategory == "Install" {
+		switch w.currentCategory {
+		case "Install":
 			w.refreshInstallStatusAsync()
+		case "LSP":
+			w.refreshLSPStatusAsync()
 		}
 	})
 
@@ -188,8 +191,11 @@ func (w *Wizard) pageFeatures() tview.Primitive {
 	})
 
 	w.renderFeatureTable()
-	if w.currentCategory == "Install" {
+	switch w.currentCategory {
+	case "Install":
 		w.refreshInstallStatusAsync()
+	case "LSP":
+		w.refreshLSPStatusAsync()
 	}
 
 	buttons := tview.NewForm()
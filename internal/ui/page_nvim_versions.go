@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"nvimwiz/internal/install"
+	"nvimwiz/internal/tasks"
+)
+
+func (w *Wizard) pageNvimVersions() tview.Primitive {
+	list := tview.NewList()
+	list.ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle("Neovim versions")
+
+	detail := tview.NewTextView()
+	detail.SetDynamicColors(false)
+	detail.SetBorder(true)
+	detail.SetTitle("Details")
+
+	items := []install.NvimVersion{}
+
+	reload := func() {
+		var err error
+		items, err = install.ListNvimVersions()
+		list.Clear()
+		if err != nil {
+			items = []install.NvimVersion{}
+			detail.SetText(err.Error())
+			return
+		}
+		for _, v := range items {
+			label := v.Tag
+			if v.Active {
+				label += "  (active)"
+			}
+			list.AddItem(label, "", 0, nil)
+		}
+		if len(items) == 0 {
+			detail.SetText("No Neovim versions installed by nvimwiz yet.\n\nEnable install.neovim and run Apply to install one.")
+			return
+		}
+		list.SetCurrentItem(0)
+		renderNvimVersionDetail(detail, items[0])
+	}
+	w.nvimVersionsReload = reload
+
+	list.SetChangedFunc(func(index int, _, _ string, _ rune) {
+		if index < 0 || index >= len(items) {
+			return
+		}
+		renderNvimVersionDetail(detail, items[index])
+	})
+
+	selected := func() (install.NvimVersion, bool) {
+		idx := list.GetCurrentItem()
+		if idx < 0 || idx >= len(items) {
+			return install.NvimVersion{}, false
+		}
+		return items[idx], true
+	}
+
+	buttons := tview.NewForm()
+	buttons.AddButton("Use", func() {
+		v, ok := selected()
+		if !ok {
+			return
+		}
+		if _, err := install.UseNvimVersion(v.Tag); err != nil {
+			w.message("Neovim", err.Error())
+			return
+		}
+		reload()
+	})
+	buttons.AddButton("Remove", func() {
+		v, ok := selected()
+		if !ok {
+			return
+		}
+		if v.Active {
+			w.message("Neovim", v.Tag+" is in use. Switch to another version first.")
+			return
+		}
+		w.confirm("Remove", "Delete Neovim "+v.Tag+" from "+v.Dir+"?", func() {
+			pins, err := tasks.NvimPins(w.cat)
+			if err != nil {
+				w.message("Remove", err.Error())
+				return
+			}
+			if err := install.RemoveNvimVersion(v.Tag, pins...); err != nil {
+				w.message("Remove", err.Error())
+			}
+			reload()
+		})
+	})
+	buttons.AddButton("Prune", func() {
+		keep := install.DefaultNvimKeep
+		w.confirm("Prune", fmt.Sprintf("Keep the active version, versions bound to a profile and the %d newest others, and delete the rest?", keep), func() {
+			pins, err := tasks.NvimPins(w.cat)
+			if err != nil {
+				w.message("Prune", err.Error())
+				return
+			}
+			removed, err := install.PruneNvimVersions(keep, pins...)
+			reload()
+			if err != nil {
+				w.message("Prune", err.Error())
+				return
+			}
+			if len(removed) == 0 {
+				w.message("Prune", "Nothing to prune")
+				return
+			}
+			w.message("Prune", "Removed: "+strings.Join(removed, ", "))
+		})
+	})
+	buttons.AddButton("Refresh", func() { reload() })
+	buttons.AddButton("Back", func() { w.gotoPage("settings") })
+	buttons.SetButtonsAlign(tview.AlignCenter)
+
+	flex := tview.NewFlex()
+	flex.AddItem(list, 0, 1, true)
+	flex.AddItem(detail, 0, 2, false)
+
+	wrap := tview.NewFlex().SetDirection(tview.FlexRow)
+	wrap.AddItem(flex, 0, 1, true)
+	wrap.AddItem(buttons, 3, 0, true)
+	return wrap
+}
+
+func renderNvimVersionDetail(tv *tview.TextView, v install.NvimVersion) {
+	lines := []string{}
+	lines = append(lines, "Version: "+v.Tag)
+	if v.Active {
+		lines = append(lines, "Active: yes (~/.local/bin/nvim points here)")
+	} else {
+		lines = append(lines, "Active: no")
+	}
+	lines = append(lines, "Directory: "+v.Dir)
+	lines = append(lines, "Binary: "+v.Bin)
+	if !v.ModTime.IsZero() {
+		lines = append(lines, "Installed: "+v.ModTime.Format("2006-01-02 15:04"))
+	}
+	tv.SetText(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"nvimwiz/internal/tasks"
+)
+
+func (w *Wizard) pagePreview() tview.Primitive {
+	tv := tview.NewTextView()
+	tv.SetDynamicColors(false)
+	tv.SetScrollable(true)
+	tv.SetBorder(true)
+	tv.SetTitle("Preview")
+
+	w.previewView = tv
+
+	buttons := tview.NewForm()
+	buttons.AddButton("Back", func() { w.gotoPage("summary") })
+	buttons.AddButton("Apply", func() {
+		w.gotoPage("apply")
+		w.startApply()
+	})
+	buttons.AddButton("Quit", func() { w.app.Stop() })
+	buttons.SetButtonsAlign(tview.AlignCenter)
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.AddItem(tv, 0, 1, false)
+	flex.AddItem(buttons, 3, 0, true)
+	return flex
+}
+
+func (w *Wizard) renderPreview() {
+	if w.previewView == nil {
+		return
+	}
+
+	plan := tasks.Plan(w.p, w.cat)
+	w.taskPlan = plan
+
+	w.previewView.SetText("Checking releases and config files...")
+	w.previewSeq++
+	seq := w.previewSeq
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		lines := []string{"Dry run: nothing has been changed yet.", ""}
+		lines = append(lines, tasks.FormatPreview(tasks.Preview(ctx, plan))...)
+		text := strings.Join(lines, "\n")
+
+		w.app.QueueUpdateDraw(func() {
+			if seq != w.previewSeq {
+				return
+			}
+			w.previewView.SetText(text)
+			w.previewView.ScrollToBeginning()
+		})
+	}()
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/rivo/tview"
 
 	"nvimwiz/internal/profile"
+	"nvimwiz/internal/tasks"
 )
 
 func attachSettingsHelp(w *Wizard, form *tview.Form, key string) {
@@ -171,6 +172,15 @@ func (w *Wizard) pageSettings() tview.Primitive {
 		})
 		attachSettingsHelp(w, fields, "build_name")
 		track("build_name", "Build name")
+
+		fields.AddInputField("Neovim version", w.p.NvimVersion, fieldWidth, nil, func(text string) {
+			w.p.NvimVersion = strings.TrimSpace(text)
+			w.p.Normalize(w.cat)
+			_ = profile.Save(w.p)
+			w.showSettingsFieldHelp("nvim_version")
+		})
+		attachSettingsHelp(w, fields, "nvim_version")
+		track("nvim_version", "Neovim version")
 	}
 
 	presetLabels := []string{}
@@ -269,25 +279,39 @@ func (w *Wizard) pageSettings() tview.Primitive {
 	attachSettingsHelp(w, fields, "local_leader")
 	track("local_leader", "Local leader")
 
-	verifyLabels := []string{"auto", "require", "off"}
-	verifyIndex := 0
-	for i, v := range verifyLabels {
-		if v == w.p.Verify {
-			verifyIndex = i
-			break
+	// "signed" is only offered while some enabled tool has a signing key;
+	// the options are rebuilt when the page is shown again, since the
+	// features page may have changed which tools are enabled.
+	verify := tview.NewDropDown().SetLabel("Verify")
+	reloadingVerify := false
+	w.verifyReload = func() {
+		labels := []string{"auto", "require"}
+		if w.p.Verify == "signed" || tasks.SignedVerifiable(w.p) {
+			labels = append(labels, "signed")
 		}
-	}
-	fields.AddDropDown("Verify", verifyLabels, verifyIndex, func(_ string, index int) {
-		if initializing {
-			return
-		}
-		if index >= 0 && index < len(verifyLabels) {
-			w.p.Verify = verifyLabels[index]
+		labels = append(labels, "off")
+		current := 0
+		for i, v := range labels {
+			if v == w.p.Verify {
+				current = i
+				break
+			}
 		}
-		w.p.Normalize(w.cat)
-		_ = profile.Save(w.p)
-		w.showSettingsFieldHelp("verify")
-	})
+		reloadingVerify = true
+		verify.SetOptions(labels, func(text string, _ int) {
+			if initializing || reloadingVerify {
+				return
+			}
+			w.p.Verify = text
+			w.p.Normalize(w.cat)
+			_ = profile.Save(w.p)
+			w.showSettingsFieldHelp("verify")
+		})
+		verify.SetCurrentOption(current)
+		reloadingVerify = false
+	}
+	w.verifyReload()
+	fields.AddFormItem(verify)
 	attachSettingsHelp(w, fields, "verify")
 	track("verify", "Verify")
 
@@ -304,6 +328,9 @@ func (w *Wizard) pageSettings() tview.Primitive {
 	buttons.AddButton("Show System", func() {
 		w.showSystemModal()
 	})
+	buttons.AddButton("Neovim", func() {
+		w.gotoPage("nvim_versions")
+	})
 	buttons.AddButton("Next", func() {
 		w.p.Normalize(w.cat)
 		_ = profile.Save(w.p)
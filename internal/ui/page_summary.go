@@ -21,6 +21,7 @@ func (w *Wizard) pageSummary() tview.Primitive {
 
 	buttons := tview.NewForm()
 	buttons.AddButton("Back", func() { w.gotoPage("features") })
+	buttons.AddButton("Preview", func() { w.gotoPage("preview") })
 	buttons.AddButton("Apply", func() {
 		w.gotoPage("apply")
 		w.startApply()
@@ -49,6 +50,9 @@ func (w *Wizard) renderSummary() {
 	lines = append(lines, "Config mode: "+w.p.ConfigMode)
 	lines = append(lines, "Verify: "+w.p.Verify)
 	lines = append(lines, "Projects dir: "+w.p.ProjectsDir)
+	if v := w.p.BoundNvimVersion(); v != "" {
+		lines = append(lines, "Neovim for launcher: "+v)
+	}
 	lines = append(lines, "")
 
 	lines = append(lines, "Choices:")
@@ -1,12 +1,35 @@
 package ui
 
-import "github.com/rivo/tview"
+import (
+	"strconv"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"nvimwiz/internal/tasks"
+)
 
 func (w *Wizard) pageWelcome() tview.Primitive {
+	text := "nvimwiz\n\nA setup wizard for a modular Neovim config.\n\nPress Start to configure presets and features."
+
+	last, resumable, err := w.resumableJournal()
+	if err != nil {
+		text += "\n\n[yellow]" + tview.Escape(err.Error()) + "[-]\nStart a new run to replace it."
+	}
+	if resumable {
+		done := strconv.Itoa(len(last.Completed)) + "/" + strconv.Itoa(len(last.Steps))
+		when := last.UpdatedAt
+		if t, err := time.Parse(time.RFC3339, last.UpdatedAt); err == nil {
+			when = t.Format("2006-01-02 15:04")
+		}
+		text += "\n\nThe last run of profile " + last.Profile + " did not finish (" + done + " steps done, " + when + ")." +
+			"\nPress Resume last run to continue where it stopped."
+	}
+
 	tv := tview.NewTextView()
 	tv.SetDynamicColors(true)
 	tv.SetTextAlign(tview.AlignLeft)
-	tv.SetText("nvimwiz\n\nA setup wizard for a modular Neovim config.\n\nPress Start to configure presets and features.")
+	tv.SetText(text)
 	tv.SetBorder(true)
 	tv.SetTitle("Welcome")
 
@@ -14,6 +37,11 @@ func (w *Wizard) pageWelcome() tview.Primitive {
 	form.AddButton("Start", func() {
 		w.gotoPage("settings")
 	})
+	if resumable {
+		form.AddButton("Resume last run", func() {
+			w.resumeLastRun(last)
+		})
+	}
 	form.AddButton("Quit", func() {
 		w.app.Stop()
 	})
@@ -24,3 +52,16 @@ func (w *Wizard) pageWelcome() tview.Primitive {
 	flex.AddItem(form, 3, 0, true)
 	return flex
 }
+
+// resumableJournal returns the last run's journal when it is unfinished and
+// the current profile has not changed since it was recorded.
+func (w *Wizard) resumableJournal() (*tasks.Journal, bool, error) {
+	j, ok, err := tasks.LoadJournal()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if _, ok := j.ResumeIndex(w.p, tasks.Plan(w.p, w.cat)); !ok {
+		return nil, false, nil
+	}
+	return j, true, nil
+}
@@ -78,6 +78,24 @@ func (w *Wizard) showSettingsFieldHelp(fieldKey string) {
 			)
 		}
 
+	case "nvim_version":
+		cur := strings.TrimSpace(w.p.NvimVersion)
+		if cur == "" {
+			cur = "(not set, the launcher runs the nvim on PATH)"
+		}
+		lines = append(lines,
+			"Info: Neovim version",
+			"",
+			"Binds this safe build to one Neovim release, for example v0.10.2.",
+			"Apply installs it under ~/.local/nvim/<tag> without changing ~/.local/bin/nvim,",
+			"and the launcher runs that binary directly.",
+			"",
+			"Use a fixed tag to keep a build working when the system nvim moves on.",
+			"Leave empty to use whatever nvim is first on PATH.",
+			"",
+			"Current: "+cur,
+		)
+
 	case "preset":
 		lines = append(lines, w.presetHelp()...)
 
@@ -144,6 +162,12 @@ func (w *Wizard) showSettingsFieldHelp(fieldKey string) {
 			"require:",
 			"- fail if verification is not possible",
 			"",
+			"signed:",
+			"- require a minisign or cosign-style signature by a pinned key",
+			"  (add keys under \"signingKeys\" in the profile)",
+			"- only offered once an enabled tool has a key in the profile;",
+			"  nvimwiz ships none",
+			"",
 			"off:",
 			"- skip verification",
 			"",
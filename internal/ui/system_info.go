@@ -34,6 +34,10 @@ func (w *Wizard) systemInfoText() string {
 		lines = append(lines, "Arch: "+w.sys.GOARCH)
 	}
 
+	if w.sys.Libc.Flavor != "" {
+		lines = append(lines, "C library: "+w.sys.Libc.String())
+	}
+
 	if w.sys.WSL {
 		lines = append(lines, "WSL: yes")
 	} else {
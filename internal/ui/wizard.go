@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -53,14 +54,26 @@ type Wizard struct {
 	installStatusLast    time.Time
 	installStatusRunning bool
 
+	lspStatus        map[string]install.ServerStatus
+	lspNodeOK        bool
+	lspStatusLast    time.Time
+	lspStatusRunning bool
+
 	logView      *tview.TextView
 	progressView *tview.TextView
 	summaryView  *tview.TextView
+	previewView  *tview.TextView
+	previewSeq   int
+
+	nvimVersionsReload func()
+	verifyReload       func()
 
 	taskPlan []tasks.Task
 
 	taskState        *tasks.State
 	applyFailedIndex int
+	applyCancel      context.CancelFunc
+	journal          *tasks.Journal
 }
 
 func New(app *tview.Application) (*Wizard, error) {
@@ -93,7 +106,9 @@ func (w *Wizard) Run() error {
 	w.pages.AddPage("settings", w.pageSettings(), true, false)
 	w.pages.AddPage("features", w.pageFeatures(), true, false)
 	w.pages.AddPage("summary", w.pageSummary(), true, false)
+	w.pages.AddPage("preview", w.pagePreview(), true, false)
 	w.pages.AddPage("apply", w.pageApply(), true, false)
+	w.pages.AddPage("nvim_versions", w.pageNvimVersions(), true, false)
 
 	w.app.SetRoot(w.pages, true)
 	w.app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
@@ -110,6 +125,15 @@ func (w *Wizard) gotoPage(name string) {
 	if name == "summary" {
 		w.renderSummary()
 	}
+	if name == "preview" {
+		w.renderPreview()
+	}
+	if name == "nvim_versions" && w.nvimVersionsReload != nil {
+		w.nvimVersionsReload()
+	}
+	if name == "settings" && w.verifyReload != nil {
+		w.verifyReload()
+	}
 	w.pages.SwitchToPage(name)
 }
 func (w *Wizard) applyPreset(presetID string) {